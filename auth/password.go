@@ -1,15 +1,64 @@
 package auth
 
 import (
+	"crypto/rand"
+	"sync"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// passwordAlphabet leaves out characters that are easy to confuse when read aloud.
+const passwordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// PasswordCost is the bcrypt cost for new hashes. Hashes made with a lower
+// cost are upgraded the next time their owner logs in.
+const PasswordCost = 12
+
+// MinPasswordLength is the shortest password users may choose themselves.
+const MinPasswordLength = 8
+
 func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
 	return string(hash), err
 }
 
+// NeedsRehash reports whether hash was made with a weaker cost than PasswordCost.
+func NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < PasswordCost
+}
+
+// RandomPassword returns a random password of n characters, used for
+// accounts created on behalf of someone else.
+func RandomPassword(n int) (string, error) {
+	// Reject bytes past the last full multiple of the alphabet to avoid bias
+	limit := 256 - 256%len(passwordAlphabet)
+	out := make([]byte, 0, n)
+	buf := make([]byte, 1)
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		if int(buf[0]) < limit {
+			out = append(out, passwordAlphabet[int(buf[0])%len(passwordAlphabet)])
+		}
+	}
+	return string(out), nil
+}
+
 func CheckPassword(hash, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// dummyHash is only ever compared against, see FakeCheckPassword.
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("no account has this password")
+	return hash
+})
+
+// FakeCheckPassword spends as long as CheckPassword without a real hash, so
+// a login for a missing user takes as long to reject as a wrong password.
+func FakeCheckPassword(password string) {
+	CheckPassword(dummyHash(), password)
+}
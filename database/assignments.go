@@ -1,25 +1,35 @@
 package database
 
 import (
-	"encoding/json"
 	"fmt"
 	"frontend/database/models"
-
-	"go.etcd.io/bbolt"
+	"frontend/internal/authz"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"time"
 )
 
-func CreateAssignment(s *Store, classId int, title, description, dueDate string) (*models.Assignment, error) {
+// CreateAssignment adds an assignment to a class together with an empty
+// submission for each of its students, all in one transaction.
+func CreateAssignment(s *Store, classId int, title, description string, dueDate time.Time) (*models.Assignment, error) {
 	var a *models.Assignment
-	var id64 uint64
 
-	err := s.db.Update(func(tx *bbolt.Tx) error {
-		b, err := tx.CreateBucketIfNotExists(Buckets["assignments"])
+	err := s.Tx(func(tx *Tx) error {
+		class, err := TxGet[models.Class](tx, Buckets["classes"], fmt.Sprintf("%d", classId))
+		if err != nil {
+			return err
+		}
+
+		b, err := tx.tx.CreateBucketIfNotExists(Buckets["assignments"])
 		if err != nil {
 			return err
 		}
 
 		// Generate a unique ID
-		id64, _ = b.NextSequence()
+		id64, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
 		a = &models.Assignment{
 			Id:          int(id64),
 			Title:       title,
@@ -27,53 +37,69 @@ func CreateAssignment(s *Store, classId int, title, description, dueDate string)
 			DueDate:     dueDate,
 		}
 
-		// Marshal assignment
-		data, err := json.Marshal(a)
-		if err != nil {
+		// Key format: classId:assignmentId
+		if err := TxSave(tx, Buckets["assignments"], fmt.Sprintf("%d:%d", classId, a.Id), a); err != nil {
 			return err
 		}
 
-		// Key format: classId:assignmentId
-		key := fmt.Sprintf("%d:%d", classId, a.Id)
-
-		// Save to DB
-		return b.Put([]byte(key), data)
+		for _, username := range class.Users {
+			user, err := TxGet[models.User](tx, Buckets["users"], username)
+			if err != nil {
+				slog.WarnContext(s.Context(), "skipping unknown class member", slog.String("user", username), telemetry.Err(err))
+				continue
+			}
+
+			// The role in the class decides, a professor can attend someone else's class
+			if authz.ClassRole(user, class) != models.RoleStudent {
+				continue
+			}
+
+			sub := models.Submission{Username: username, Content: []string{}}
+			if err := TxSave(tx, Buckets["submissions"], fmt.Sprintf("%d:%d:%s", classId, a.Id, username), sub); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 
 	if err != nil {
-		fmt.Printf("❌ [CreateAssignment] failed: %v\n", err)
+		slog.ErrorContext(s.Context(), "failed to create assignment", slog.Int("class", classId), telemetry.Err(err))
 		return nil, err
 	}
 
-	fmt.Printf("✅ [CreateAssignment] Stored assignment %+v\n", a)
+	slog.DebugContext(s.Context(), "stored assignment", slog.Int("class", classId), slog.Int("assignment", a.Id))
+	return a, nil
+}
+
+// DeleteAssignment removes an assignment and all its submissions in one
+// transaction. They are returned so the caller can delete their files once
+// the records are gone.
+func DeleteAssignment(s *Store, classId, assignmentId int) (*models.Assignment, []*models.Submission, error) {
+	var a *models.Assignment
+	var subs []*models.Submission
 
-	class, err := GetWithPrefix[models.Class](s, Buckets["classes"], fmt.Sprintf("%d", classId))
-	if err != nil {
-		fmt.Printf("X Error getting class: %v\n", err)
-		return nil, err
-	}
+	err := s.Tx(func(tx *Tx) error {
+		key := fmt.Sprintf("%d:%d", classId, assignmentId)
 
-	for _, username := range class.Users {
-		user, err := Get[models.User](s, Buckets["users"], username)
+		var err error
+		a, err = TxGet[models.Assignment](tx, Buckets["assignments"], key)
 		if err != nil {
-			fmt.Printf("X Error getting user: %v\n", err)
-			err = nil
-			continue
+			return err
 		}
-
-		if user.Role != "student" {
-			continue
+		subs, err = TxListByPrefix[models.Submission](tx, Buckets["submissions"], key)
+		if err != nil {
+			return err
 		}
 
-		_, err = CreateSubmission(s, classId, int(id64), username, "", []string{}, "", "")
-		if err != nil {
-			fmt.Printf("X Error creating user submission: %v\n", err)
-			err = nil
-			continue
+		if _, err := TxDeleteByPrefix(tx, Buckets["submissions"], key); err != nil {
+			return err
 		}
+		return TxDelete(tx, Buckets["assignments"], key)
+	})
+	if err != nil {
+		return nil, nil, err
 	}
-
-	return a, nil
+	return a, subs, nil
 }
 
 func ListAssignmentsOfClass(store *Store, classID int) []*models.Assignment {
@@ -86,7 +112,7 @@ func ListAssignmentsOfClass(store *Store, classID int) []*models.Assignment {
 	)
 
 	if err != nil {
-		fmt.Printf("Error listing assignments of class")
+		slog.ErrorContext(store.Context(), "failed to list assignments", slog.Int("class", classID), telemetry.Err(err))
 		return []*models.Assignment{}
 	}
 
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// WriteBackup writes a consistent copy of the whole database to w. It runs
+// in a read transaction, so requests keep being served while it copies.
+func WriteBackup(s *Store, w io.Writer) (int64, error) {
+	var n int64
+	err := s.view(func(tx *bbolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+// ValidateBackup checks that the file at path is a sound database this
+// binary can run on: its pages are consistent, it has the buckets and its
+// schema is not newer than SchemaVersion. It only reads the file.
+func ValidateBackup(path string) error {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("not a database: %w", err)
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bbolt.Tx) error {
+		var errs []error
+		for err := range tx.Check() {
+			errs = append(errs, err)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("corrupt database: %w", errors.Join(errs...))
+		}
+
+		for _, name := range []string{"users", "classes", "assignments", "submissions"} {
+			if tx.Bucket(Buckets[name]) == nil {
+				return fmt.Errorf("bucket %s missing", Buckets[name])
+			}
+		}
+
+		version, err := schemaVersionTx(tx)
+		if err != nil {
+			return err
+		}
+		if version > SchemaVersion() {
+			return fmt.Errorf("%w: backup is at version %d, binary at %d", ErrSchemaTooNew, version, SchemaVersion())
+		}
+		return nil
+	})
+}
+
+// Compact rewrites the database file at path without the free pages bbolt
+// keeps after deletes, and returns its size before and after. The old file
+// is kept as path+".pre-compact". Nothing else may have the file open.
+func Compact(path string) (before, after int64, err error) {
+	src, err := bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return 0, 0, openError(path, err)
+	}
+	defer src.Close()
+
+	tmp := path + ".compact"
+	_ = os.Remove(tmp)
+	dst, err := bbolt.Open(tmp, 0600, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.Remove(tmp)
+	err = bbolt.Compact(dst, src, 64<<20)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if before, err = fileSize(path); err != nil {
+		return 0, 0, err
+	}
+	if after, err = fileSize(tmp); err != nil {
+		return 0, 0, err
+	}
+	if err := os.Rename(path, path+".pre-compact"); err != nil {
+		return 0, 0, err
+	}
+	return before, after, os.Rename(tmp, path)
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
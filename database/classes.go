@@ -11,7 +11,7 @@ import (
 
 func CreateClass(s *Store, name, description, subject string) (*models.Class, error) {
 	var c *models.Class
-	err := s.db.Update(func(tx *bbolt.Tx) error {
+	err := s.update(func(tx *bbolt.Tx) error {
 		b, err := tx.CreateBucketIfNotExists(Buckets["classes"])
 		if err != nil {
 			return err
@@ -36,7 +36,10 @@ func CreateClass(s *Store, name, description, subject string) (*models.Class, er
 		}
 
 		key := fmt.Sprintf("%d", c.Id)
-		return b.Put([]byte(key), data)
+		if err := b.Put([]byte(key), data); err != nil {
+			return err
+		}
+		return indexClassTx(tx, nil, c)
 	})
 	if err != nil {
 		return nil, err
@@ -45,56 +48,54 @@ func CreateClass(s *Store, name, description, subject string) (*models.Class, er
 }
 
 func updateClass(s *Store, classId int, updater func(*models.Class) error) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(Buckets["classes"])
-		if b == nil {
-			return fmt.Errorf("bucket %s not found", Buckets["classes"])
-		}
-
-		key := []byte(fmt.Appendf(nil, "%d", classId))
-		v := b.Get(key)
-		if v == nil {
-			return fmt.Errorf("class %d not found", classId)
-		}
-
-		var c models.Class
-		if err := json.Unmarshal(v, &c); err != nil {
-			return err
-		}
-
-		// Apply caller's logic
-		if err := updater(&c); err != nil {
-			return err
-		}
-
-		data, _ := json.Marshal(c)
-		return b.Put(key, data)
+	return s.update(func(tx *bbolt.Tx) error {
+		return updateClassTx(tx, classId, updater)
 	})
 }
 
-func ListClassesForUser(s *Store, username string) ([]*models.Class, error) {
-	var results []*models.Class
+// updateClassTx is updateClass for callers already inside a transaction.
+func updateClassTx(tx *bbolt.Tx, classId int, updater func(*models.Class) error) error {
+	b := tx.Bucket(Buckets["classes"])
+	if b == nil {
+		return fmt.Errorf("bucket %s not found", Buckets["classes"])
+	}
+
+	key := []byte(fmt.Appendf(nil, "%d", classId))
+	v := b.Get(key)
+	if v == nil {
+		return fmt.Errorf("class %d not found", classId)
+	}
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(Buckets["classes"])
-		if b == nil {
-			return fmt.Errorf("bucket %s not found", Buckets["classes"])
-		}
+	var c, old models.Class
+	if err := json.Unmarshal(v, &c); err != nil {
+		return err
+	}
+	old = c
+	old.Users = slices.Clone(c.Users)
 
-		c := b.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			var class models.Class
-			if err := json.Unmarshal(v, &class); err != nil {
-				return err
-			}
+	// Apply caller's logic
+	if err := updater(&c); err != nil {
+		return err
+	}
 
-			if slices.Contains(class.Users, username) {
-				classCopy := class
-				results = append(results, &classCopy)
-			}
-		}
-		return nil
+	data, _ := json.Marshal(c)
+	if err := b.Put(key, data); err != nil {
+		return err
+	}
+	return indexClassTx(tx, &old, &c)
+}
+
+func ListClasses(s *Store) ([]*models.Class, error) {
+	classes, err := List[models.Class](s, Buckets["classes"])
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(classes, func(a, b *models.Class) int {
+		return a.Id - b.Id
 	})
+	return classes, nil
+}
 
-	return results, err
+func GetClass(s *Store, classId int) (*models.Class, error) {
+	return GetWithPrefix[models.Class](s, Buckets["classes"], fmt.Sprintf("%d", classId))
 }
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"frontend/database/models"
+	"slices"
+	"strconv"
+
+	"go.etcd.io/bbolt"
+)
+
+// The index buckets hold "<from>:<to>" keys with empty values, so a prefix
+// scan on <from> lists the other side of the relation without reading every
+// class. They are written in the same transaction as the class, by
+// CreateClass and updateClassTx, and can be rebuilt with RebuildIndexes.
+var indexBuckets = []string{"user_classes", "class_members", "subject_classes"}
+
+func indexKey(from, to string) []byte {
+	return []byte(from + ":" + to)
+}
+
+// indexedTx lists the keys related to from in an index bucket
+func indexedTx(tx *bbolt.Tx, bucket, from string) ([]string, error) {
+	b := tx.Bucket(Buckets[bucket])
+	if b == nil {
+		return nil, fmt.Errorf("bucket %s not found", Buckets[bucket])
+	}
+
+	var out []string
+	p := []byte(from + ":")
+	c := b.Cursor()
+	for k, _ := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, _ = c.Next() {
+		out = append(out, string(k[len(p):]))
+	}
+	return out, nil
+}
+
+// indexClassTx brings the indexes from the old state of a class to the new
+// one; old is nil for a new class
+func indexClassTx(tx *bbolt.Tx, old, c *models.Class) error {
+	id := strconv.Itoa(c.Id)
+	userClasses := tx.Bucket(Buckets["user_classes"])
+	classMembers := tx.Bucket(Buckets["class_members"])
+	subjectClasses := tx.Bucket(Buckets["subject_classes"])
+	if userClasses == nil || classMembers == nil || subjectClasses == nil {
+		return fmt.Errorf("index buckets not found")
+	}
+
+	var oldUsers []string
+	if old != nil {
+		oldUsers = old.Users
+		if old.Subject != c.Subject {
+			if err := subjectClasses.Delete(indexKey(old.Subject, id)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := subjectClasses.Put(indexKey(c.Subject, id), nil); err != nil {
+		return err
+	}
+
+	for _, u := range oldUsers {
+		if slices.Contains(c.Users, u) {
+			continue
+		}
+		if err := userClasses.Delete(indexKey(u, id)); err != nil {
+			return err
+		}
+		if err := classMembers.Delete(indexKey(id, u)); err != nil {
+			return err
+		}
+	}
+	for _, u := range c.Users {
+		if slices.Contains(oldUsers, u) {
+			continue
+		}
+		if err := userClasses.Put(indexKey(u, id), nil); err != nil {
+			return err
+		}
+		if err := classMembers.Put(indexKey(id, u), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// classesTx reads the classes with the given ids. A missing one means the
+// indexes are out of step with the classes.
+func classesTx(tx *bbolt.Tx, ids []string) ([]*models.Class, error) {
+	results := make([]*models.Class, 0, len(ids))
+	for _, id := range ids {
+		class, err := getTx[models.Class](tx, Buckets["classes"], id)
+		if err != nil {
+			return nil, err
+		}
+		if class == nil {
+			return nil, fmt.Errorf("index points to missing class %s, rebuild the indexes", id)
+		}
+		results = append(results, class)
+	}
+	return results, nil
+}
+
+// ListClassesForUser returns the classes username is a member of
+func ListClassesForUser(s *Store, username string) ([]*models.Class, error) {
+	var results []*models.Class
+	err := s.view(func(tx *bbolt.Tx) error {
+		ids, err := indexedTx(tx, "user_classes", username)
+		if err != nil {
+			return err
+		}
+		results, err = classesTx(tx, ids)
+		return err
+	})
+	return results, err
+}
+
+// ListClassesForSubject returns the classes of a subject
+func ListClassesForSubject(s *Store, subject string) ([]*models.Class, error) {
+	var results []*models.Class
+	err := s.view(func(tx *bbolt.Tx) error {
+		ids, err := indexedTx(tx, "subject_classes", subject)
+		if err != nil {
+			return err
+		}
+		results, err = classesTx(tx, ids)
+		return err
+	})
+	return results, err
+}
+
+// ListClassMembers returns the usernames of the members of a class, sorted
+func ListClassMembers(s *Store, classId int) ([]string, error) {
+	var members []string
+	err := s.view(func(tx *bbolt.Tx) error {
+		var err error
+		members, err = indexedTx(tx, "class_members", strconv.Itoa(classId))
+		return err
+	})
+	return members, err
+}
+
+// RebuildIndexes empties the index buckets and fills them again from the
+// classes. It returns the number of classes indexed.
+func RebuildIndexes(s *Store) (int, error) {
+	var n int
+	err := s.update(func(tx *bbolt.Tx) error {
+		var err error
+		n, err = rebuildIndexesTx(tx)
+		return err
+	})
+	return n, err
+}
+
+func rebuildIndexesTx(tx *bbolt.Tx) (int, error) {
+	for _, name := range indexBuckets {
+		if tx.Bucket(Buckets[name]) != nil {
+			if err := tx.DeleteBucket(Buckets[name]); err != nil {
+				return 0, err
+			}
+		}
+		if _, err := tx.CreateBucket(Buckets[name]); err != nil {
+			return 0, err
+		}
+	}
+
+	b := tx.Bucket(Buckets["classes"])
+	if b == nil {
+		return 0, fmt.Errorf("bucket %s not found", Buckets["classes"])
+	}
+	var classes []*models.Class
+	err := b.ForEach(func(k, v []byte) error {
+		var class models.Class
+		if err := json.Unmarshal(v, &class); err != nil {
+			return err
+		}
+		classes = append(classes, &class)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, class := range classes {
+		if err := indexClassTx(tx, nil, class); err != nil {
+			return 0, err
+		}
+	}
+	return len(classes), nil
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"fmt"
+	"frontend/database/models"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go.etcd.io/bbolt"
+)
+
+// Each user of the bench school is in classesPerUser classes with
+// membersPerClass members, and each subject has classesPerSubject classes, so
+// a lookup returns the same number of rows whatever the size of the school.
+const (
+	classesPerUser    = 8
+	membersPerClass   = 30
+	classesPerSubject = 10
+)
+
+var benchSizes = []int{100, 1000, 10000}
+
+// benchStore opens a scratch database with n classes, written through the
+// same indexing as CreateClass. bbolt only splits pages on commit, so they go
+// in batches: one huge transaction gets slower with every insert.
+func benchStore(b *testing.B, n int) *Store {
+	b.Helper()
+	s, _, err := Open(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(s.Close)
+
+	const batch = 500
+	for first := 1; first <= n; first += batch {
+		err = s.update(func(tx *bbolt.Tx) error {
+			for i := first; i < first+batch && i <= n; i++ {
+				c := &models.Class{Id: i, Name: fmt.Sprintf("Clase %d", i), Subject: benchSubject((i - 1) / classesPerSubject)}
+				for j := range membersPerClass {
+					c.Users = append(c.Users, benchUser((i-1)/classesPerUser, j))
+				}
+				if err := saveTx(tx, Buckets["classes"], fmt.Sprintf("%d", i), c); err != nil {
+					return err
+				}
+				if err := indexClassTx(tx, nil, c); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	return s
+}
+
+func benchUser(group, i int) string {
+	return fmt.Sprintf("u%d-%d", group, i)
+}
+
+func benchSubject(i int) string {
+	return fmt.Sprintf("materia%d", i)
+}
+
+// BenchmarkListClassesForUser should stay flat across the sizes
+func BenchmarkListClassesForUser(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("classes=%d", n), func(b *testing.B) {
+			s := benchStore(b, n)
+			groups := n / classesPerUser
+			i := 0
+			for b.Loop() {
+				classes, err := ListClassesForUser(s, benchUser(i%groups, i%membersPerClass))
+				if err != nil || len(classes) != classesPerUser {
+					b.Fatalf("got %d classes, %v", len(classes), err)
+				}
+				i++
+			}
+		})
+	}
+}
+
+// BenchmarkListClassesForUserScan is the lookup without the index, reading
+// every class, and grows with the school
+func BenchmarkListClassesForUserScan(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("classes=%d", n), func(b *testing.B) {
+			s := benchStore(b, n)
+			groups := n / classesPerUser
+			i := 0
+			for b.Loop() {
+				all, err := ListClasses(s)
+				if err != nil {
+					b.Fatal(err)
+				}
+				username := benchUser(i%groups, i%membersPerClass)
+				found := 0
+				for _, c := range all {
+					if slices.Contains(c.Users, username) {
+						found++
+					}
+				}
+				if found != classesPerUser {
+					b.Fatalf("got %d classes", found)
+				}
+				i++
+			}
+		})
+	}
+}
+
+// BenchmarkListClassMembers should stay flat across the sizes
+func BenchmarkListClassMembers(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("classes=%d", n), func(b *testing.B) {
+			s := benchStore(b, n)
+			i := 0
+			for b.Loop() {
+				members, err := ListClassMembers(s, i%n+1)
+				if err != nil || len(members) != membersPerClass {
+					b.Fatalf("got %d members, %v", len(members), err)
+				}
+				i++
+			}
+		})
+	}
+}
+
+// BenchmarkListClassesForSubject should stay flat across the sizes
+func BenchmarkListClassesForSubject(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("classes=%d", n), func(b *testing.B) {
+			s := benchStore(b, n)
+			subjects := n / classesPerSubject
+			i := 0
+			for b.Loop() {
+				classes, err := ListClassesForSubject(s, benchSubject(i%subjects))
+				if err != nil || len(classes) != classesPerSubject {
+					b.Fatalf("got %d classes, %v", len(classes), err)
+				}
+				i++
+			}
+		})
+	}
+}
+
+// BenchmarkListClassesForSubjectScan is the subject lookup without the
+// index, reading every class, and grows with the school
+func BenchmarkListClassesForSubjectScan(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("classes=%d", n), func(b *testing.B) {
+			s := benchStore(b, n)
+			subjects := n / classesPerSubject
+			i := 0
+			for b.Loop() {
+				all, err := ListClasses(s)
+				if err != nil {
+					b.Fatal(err)
+				}
+				subject := benchSubject(i % subjects)
+				found := 0
+				for _, c := range all {
+					if c.Subject == subject {
+						found++
+					}
+				}
+				if found != classesPerSubject {
+					b.Fatalf("got %d classes", found)
+				}
+				i++
+			}
+		})
+	}
+}
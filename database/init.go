@@ -1,14 +1,20 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"frontend/auth"
 	"frontend/database/models"
-	"log"
+	"frontend/helper"
+	"frontend/internal/telemetry"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"time"
 
 	"go.etcd.io/bbolt"
+	bberrors "go.etcd.io/bbolt/errors"
 )
 
 var Buckets = map[string][]byte{
@@ -19,22 +25,40 @@ var Buckets = map[string][]byte{
 	"submissions": []byte("Submissions"),
 	"schools":     []byte("Schools"),
 	"sessions":    []byte("Sessions"),
+	"resets":      []byte("PasswordResets"),
+	"logins":      []byte("LoginAttempts"),
+	"tokens":      []byte("APITokens"),
+	"meta":        []byte("Meta"),
+
+	// Indexes, see indexes.go
+	"user_classes":    []byte("UserClasses"),
+	"class_members":   []byte("ClassMembers"),
+	"subject_classes": []byte("SubjectClasses"),
+}
+
+// InitOptions say how a new database is set up
+type InitOptions struct {
+	TimeZone string // zone of the default school
+	Seed     bool   // add sample users, a class and an assignment
+	// SeedOut gets the random passwords of the sample users, shown only once
+	SeedOut io.Writer
 }
 
-// Init opens (or creates) the DB and seeds test data if new
-func Init(path string) (*Store, error) {
+// Open opens (or creates) the DB file with all its buckets, without
+// migrating or seeding it. A new file is stamped with the current schema
+// version, as there is nothing in it to migrate.
+func Open(path string) (store *Store, created bool, err error) {
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	newDB := false
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		newDB = true
+		created = true
 	}
 
-	db, err := bbolt.Open(path, 0600, nil)
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
 	if err != nil {
-		return nil, err
+		return nil, false, openError(path, err)
 	}
 
 	// Create buckets
@@ -45,22 +69,75 @@ func Init(path string) (*Store, error) {
 				return err
 			}
 		}
+		if created {
+			return setSchemaVersionTx(tx, SchemaVersion())
+		}
 		return nil
 	})
 	if err != nil {
 		db.Close()
+		return nil, false, err
+	}
+
+	return &Store{db: db}, created, nil
+}
+
+// openError explains the timeout bbolt gives when another process holds the file
+func openError(path string, err error) error {
+	if errors.Is(err, bberrors.ErrTimeout) {
+		return fmt.Errorf("%s is in use by another process, stop the server first", path)
+	}
+	return err
+}
+
+// Init opens (or creates) the DB, migrates it and sets up a new one as opts
+// says. It refuses databases migrated by a newer binary.
+func Init(path string, opts InitOptions) (*Store, error) {
+	store, newDB, err := Open(path)
+	if err != nil {
 		return nil, err
 	}
 
-	store := &Store{db: db}
+	results, err := Migrate(store, false)
+	for _, r := range results {
+		slog.Info("applied migration", slog.Int("version", r.Version), slog.String("name", r.Name), slog.Int("changed", r.Changed))
+	}
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	// Databases that predate schools were written in DefaultTimeZone
+	timeZone := DefaultTimeZone
+	if newDB && opts.TimeZone != "" {
+		timeZone = opts.TimeZone
+	}
+	if err := ensureDefaultSchool(store, timeZone); err != nil {
+		store.Close()
+		return nil, err
+	}
 
-	if newDB {
-		log.Println("🌱 Seeding database with test data...")
+	if newDB && opts.Seed {
+		slog.Info("seeding database with test data")
 
-		// Create sample users
-		_ = CreateUser(store, "prof1", "password", "Alice", "Smith", "professor", []byte("my-secret-key-12"))
-		if err := CreateUser(store, "student1", "password", "Bob", "Perez", "student", []byte("my-secret-key-12")); err != nil {
-			fmt.Printf("Error creating User: %v\n", err)
+		// Create sample users, with random passwords so a forgotten seed
+		// doesn't leave a known admin login
+		for _, u := range []struct{ username, first, last, role string }{
+			{"admin", "Admin", "Otero", "admin"},
+			{"prof1", "Alice", "Smith", "professor"},
+			{"student1", "Bob", "Perez", "student"},
+		} {
+			password, err := auth.RandomPassword(10)
+			if err == nil {
+				err = CreateUser(store, u.username, password, u.first, u.last, u.role)
+			}
+			if err != nil {
+				slog.Error("failed to create seed user", slog.String("user", u.username), telemetry.Err(err))
+				continue
+			}
+			if opts.SeedOut != nil {
+				fmt.Fprintf(opts.SeedOut, "password for %s: %s\n", u.username, password)
+			}
 		}
 
 		// Create a subject
@@ -76,9 +153,10 @@ func Init(path string) (*Store, error) {
 		AddUserToClass(store, class.Id, "student1")
 
 		// Create an assignment
-		CreateAssignment(store, class.Id, "Álgebra I", "Resolver los ejercicios de la página 42", time.Now().AddDate(0, 0, 7).Format("02/01/2006"))
+		dueDate := helper.EndOfDay(time.Now().In(ClassLocation(store, class.Id)).AddDate(0, 0, 7))
+		CreateAssignment(store, class.Id, "Álgebra I", "Resolver los ejercicios de la página 42", dueDate)
 	}
 
-	log.Println("✅ Database ready at", path)
+	slog.Info("database ready", slog.String("path", path), slog.Int("schema_version", SchemaVersion()))
 	return store, nil
 }
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"frontend/database/models"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// LoginLimit is how many failed logins are tolerated before a lockout.
+type LoginLimit struct {
+	MaxFailures int
+	Window      time.Duration // failures older than this are forgotten
+}
+
+var (
+	// UserLoginLimit guards each username against password guessing
+	UserLoginLimit = LoginLimit{MaxFailures: 5, Window: 15 * time.Minute}
+	// IPLoginLimit is looser: a whole school often shares one address
+	IPLoginLimit = LoginLimit{MaxFailures: 50, Window: 15 * time.Minute}
+
+	// LockoutBase is the first lockout, doubled on every lockout after it up to LockoutMax
+	LockoutBase = time.Minute
+	LockoutMax  = time.Hour
+
+	// lockouts stop counting for the backoff after a quiet day
+	lockoutMemory = 24 * time.Hour
+)
+
+func userAttemptKey(username string) string { return "user:" + strings.ToLower(username) }
+func ipAttemptKey(ip string) string         { return "ip:" + ip }
+
+// LoginLockedUntil reports until when logins for username from ip are
+// blocked. The zero time means they are allowed.
+func LoginLockedUntil(s *Store, username, ip string, now time.Time) (time.Time, error) {
+	var until time.Time
+	err := s.view(func(tx *bbolt.Tx) error {
+		for _, key := range []string{userAttemptKey(username), ipAttemptKey(ip)} {
+			attempt, err := getTx[models.LoginAttempt](tx, Buckets["logins"], key)
+			if err != nil {
+				return err
+			}
+			if attempt != nil && attempt.LockedUntil.After(now) && attempt.LockedUntil.After(until) {
+				until = attempt.LockedUntil
+			}
+		}
+		return nil
+	})
+	return until, err
+}
+
+// RecordLoginFailure counts a failed login against both the username and the
+// address, locking whichever went over its limit.
+func RecordLoginFailure(s *Store, username, ip string, now time.Time) error {
+	return s.update(func(tx *bbolt.Tx) error {
+		if err := recordFailureTx(tx, userAttemptKey(username), UserLoginLimit, now); err != nil {
+			return err
+		}
+		return recordFailureTx(tx, ipAttemptKey(ip), IPLoginLimit, now)
+	})
+}
+
+func recordFailureTx(tx *bbolt.Tx, key string, limit LoginLimit, now time.Time) error {
+	attempt, err := getTx[models.LoginAttempt](tx, Buckets["logins"], key)
+	if err != nil {
+		return err
+	}
+	if attempt == nil {
+		attempt = &models.LoginAttempt{Key: key}
+	}
+
+	if now.Sub(attempt.LastFailure) > limit.Window {
+		attempt.Failures = 0
+	}
+	if now.Sub(attempt.LastFailure) > lockoutMemory {
+		attempt.Lockouts = 0
+	}
+
+	attempt.Failures++
+	attempt.LastFailure = now
+
+	if attempt.Failures >= limit.MaxFailures {
+		lock := LockoutBase << min(attempt.Lockouts, 16)
+		attempt.LockedUntil = now.Add(min(lock, LockoutMax))
+		attempt.Lockouts++
+		attempt.Failures = 0
+		slog.Warn("login locked", slog.String("key", key), slog.Time("until", attempt.LockedUntil))
+	}
+
+	return saveTx(tx, Buckets["logins"], key, attempt)
+}
+
+// RecordLoginSuccess forgets the failures of username. The address keeps its
+// count, otherwise one valid account would reset the limit for guessing others.
+func RecordLoginSuccess(s *Store, username string) error {
+	return Delete(s, Buckets["logins"], userAttemptKey(username))
+}
+
+// ListLockedLogins returns the usernames and addresses locked at now, the
+// ones locked the longest first.
+func ListLockedLogins(s *Store, now time.Time) ([]*models.LoginAttempt, error) {
+	attempts, err := List[models.LoginAttempt](s, Buckets["logins"])
+	if err != nil {
+		return nil, err
+	}
+
+	locked := slices.DeleteFunc(attempts, func(a *models.LoginAttempt) bool {
+		return !a.LockedUntil.After(now)
+	})
+	slices.SortFunc(locked, func(a, b *models.LoginAttempt) int {
+		return b.LockedUntil.Compare(a.LockedUntil)
+	})
+	return locked, nil
+}
+
+// UnlockLogin lifts a lockout early and forgets its failures.
+func UnlockLogin(s *Store, key string) error {
+	return Delete(s, Buckets["logins"], key)
+}
+
+// SweepLoginAttempts deletes counters that are neither locked nor remembered anymore.
+func SweepLoginAttempts(s *Store, now time.Time) error {
+	return s.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(Buckets["logins"])
+		if b == nil {
+			return fmt.Errorf("bucket %s not found", Buckets["logins"])
+		}
+
+		var keys [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var attempt models.LoginAttempt
+			if err := json.Unmarshal(v, &attempt); err != nil ||
+				(!attempt.LockedUntil.After(now) && now.Sub(attempt.LastFailure) > lockoutMemory) {
+				keys = append(keys, slices.Clone(k))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
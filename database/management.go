@@ -1,23 +1,47 @@
 package database
 
 import (
+	"fmt"
 	"frontend/database/models"
 	"frontend/helper"
 	"slices"
 )
 
 func AddUserToClass(s *Store, classId int, username string) error {
-	return updateClass(s, classId, func(c *models.Class) error {
+	return updateClass(s, classId, addMember(username))
+}
+
+// addMember is the class updater shared by AddUserToClass and the roster import.
+func addMember(username string) func(*models.Class) error {
+	return func(c *models.Class) error {
 		if !slices.Contains(c.Users, username) {
 			c.Users = append(c.Users, username)
 		}
 		return nil
-	})
+	}
 }
 
 func RemoveUserFromClass(s *Store, classId int, username string) error {
 	return updateClass(s, classId, func(c *models.Class) error {
 		c.Users = helper.Remove(c.Users, username)
+		delete(c.Roles, username)
+		return nil
+	})
+}
+
+// SetClassRole makes a member professor or student in one class only.
+func SetClassRole(s *Store, classId int, username, role string) error {
+	if !slices.Contains(ClassRoles, role) {
+		return fmt.Errorf("invalid class role %q", role)
+	}
+	return updateClass(s, classId, func(c *models.Class) error {
+		if !slices.Contains(c.Users, username) {
+			return fmt.Errorf("%s is not a member of class %d", username, classId)
+		}
+		if c.Roles == nil {
+			c.Roles = map[string]string{}
+		}
+		c.Roles[username] = role
 		return nil
 	})
 }
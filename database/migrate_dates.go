@@ -0,0 +1,110 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"frontend/database/models"
+	"frontend/helper"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// legacyDueDateLayout is how due dates were stored before they were timestamps.
+const legacyDueDateLayout = "02/01/2006"
+
+// migrateDueDates converts "30/09/2025" due dates into RFC3339 timestamps at
+// the end of that day in the class's school time zone, which keeps the old
+// "past after the end of the due day" behaviour. Already converted records
+// are left alone, so running it again is a no-op. The old form stored what
+// was typed when it didn't parse, "" included: those dates can't be
+// recovered and are cleared, leaving the assignment without a due date, and
+// each one is logged so a professor can set it again.
+func migrateDueDates(tx *bbolt.Tx) (int, error) {
+	b := tx.Bucket(Buckets["assignments"])
+	if b == nil {
+		return 0, fmt.Errorf("bucket %s not found", Buckets["assignments"])
+	}
+
+	updates := map[string][]byte{}
+	unreadable := 0
+	err := b.ForEach(func(k, v []byte) error {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(v, &raw); err != nil {
+			return err
+		}
+
+		var dueDate string
+		if err := json.Unmarshal(raw["due_date"], &dueDate); err != nil {
+			return nil // not a string, nothing to do
+		}
+		if _, err := time.Parse(time.RFC3339, dueDate); err == nil {
+			return nil
+		}
+
+		loc := classLocationTx(tx, strings.SplitN(string(k), ":", 2)[0])
+		due, err := time.ParseInLocation(legacyDueDateLayout, dueDate, loc)
+		if err == nil {
+			due = helper.EndOfDay(due)
+		} else {
+			// The zero time reads as "no due date", see helper.GetLateStatus
+			slog.Warn("clearing unreadable due date", slog.String("assignment", string(k)), slog.String("due_date", dueDate))
+			due = time.Time{}
+			unreadable++
+		}
+
+		raw["due_date"], err = json.Marshal(due)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return err
+		}
+
+		updates[string(k)] = data
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if unreadable > 0 {
+		slog.Warn("assignments left without a due date", slog.Int("count", unreadable))
+	}
+
+	// bbolt does not allow writes while iterating with ForEach
+	for k, data := range updates {
+		if err := b.Put([]byte(k), data); err != nil {
+			return 0, err
+		}
+	}
+	return len(updates), nil
+}
+
+// classLocationTx is ClassLocation for code already inside a transaction.
+func classLocationTx(tx *bbolt.Tx, classId string) *time.Location {
+	timeZone := DefaultTimeZone
+	school := DefaultSchool
+
+	if v := tx.Bucket(Buckets["classes"]).Get([]byte(classId)); v != nil {
+		var class models.Class
+		if json.Unmarshal(v, &class) == nil && class.School != "" {
+			school = class.School
+		}
+	}
+
+	if v := tx.Bucket(Buckets["schools"]).Get([]byte(school)); v != nil {
+		var sc models.School
+		if json.Unmarshal(v, &sc) == nil && sc.TimeZone != "" {
+			timeZone = sc.TimeZone
+		}
+	}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"frontend/database/models"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// legacyStore opens a scratch database at schema version 0 holding the
+// assignments as the old handler wrote them, due dates included verbatim
+func legacyStore(t *testing.T, dueDates map[string]string) *Store {
+	t.Helper()
+	s, _, err := Open(filepath.Join(t.TempDir(), "legacy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	err = s.update(func(tx *bbolt.Tx) error {
+		for key, dueDate := range dueDates {
+			a := map[string]any{"title": key, "due_date": dueDate}
+			if err := saveTx(tx, Buckets["assignments"], key, a); err != nil {
+				return err
+			}
+		}
+		return setSchemaVersionTx(tx, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMigrateDueDates(t *testing.T) {
+	s := legacyStore(t, map[string]string{
+		"1:1": "30/09/2025",
+		"1:2": "",
+		"1:3": "mañana",
+		"1:4": "2025-09-30T23:59:00+02:00",
+	})
+
+	if _, err := Migrate(s, false); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	loc, err := time.LoadLocation(DefaultTimeZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]time.Time{
+		"1:1": time.Date(2025, 9, 30, 23, 59, 0, 0, loc),
+		"1:2": {},
+		"1:3": {},
+		"1:4": time.Date(2025, 9, 30, 23, 59, 0, 0, time.FixedZone("", 2*60*60)),
+	}
+	for key, due := range want {
+		a, err := Get[models.Assignment](s, Buckets["assignments"], key)
+		if err != nil {
+			t.Fatalf("assignment %s: %v", key, err)
+		}
+		if !a.DueDate.Equal(due) || a.DueDate.IsZero() != due.IsZero() {
+			t.Errorf("assignment %s: due date %v, want %v", key, a.DueDate, due)
+		}
+	}
+
+	if v, err := GetSchemaVersion(s); err != nil || v != SchemaVersion() {
+		t.Errorf("schema version %d, %v, want %d", v, err, SchemaVersion())
+	}
+}
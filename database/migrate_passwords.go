@@ -0,0 +1,51 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// legacyPasswordField held an AES encrypted, reversible copy of each password.
+const legacyPasswordField = "password_now_hashed"
+
+// migratePasswordCopies deletes the reversible password copy from every user.
+// The bcrypt hash stays, so nobody has to change their password. Users that
+// are already clean are skipped, running it again is a no-op.
+func migratePasswordCopies(tx *bbolt.Tx) (int, error) {
+	b := tx.Bucket(Buckets["users"])
+	if b == nil {
+		return 0, fmt.Errorf("bucket %s not found", Buckets["users"])
+	}
+
+	updates := map[string][]byte{}
+	err := b.ForEach(func(k, v []byte) error {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(v, &raw); err != nil {
+			return err
+		}
+		if _, ok := raw[legacyPasswordField]; !ok {
+			return nil
+		}
+
+		delete(raw, legacyPasswordField)
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return err
+		}
+		updates[string(k)] = data
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// bbolt does not allow writes while iterating with ForEach
+	for k, data := range updates {
+		if err := b.Put([]byte(k), data); err != nil {
+			return 0, err
+		}
+	}
+	return len(updates), nil
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go.etcd.io/bbolt"
+)
+
+// schemaVersionKey is where the meta bucket keeps the applied schema version
+const schemaVersionKey = "schema_version"
+
+// Migration is one step of the schema. Up rewrites the records of the
+// previous version and returns how many it changed; it runs in the same
+// write transaction that records Version, so a failing step leaves the
+// database as it was.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *bbolt.Tx) (int, error)
+}
+
+// migrations are applied in order, each exactly once. Append new ones at the
+// end with the next version; never renumber or remove a shipped one.
+var migrations = []Migration{
+	{1, "due dates as timestamps", migrateDueDates},
+	{2, "drop reversible password copies", migratePasswordCopies},
+	{3, "class membership indexes", rebuildIndexesTx},
+}
+
+// SchemaVersion is the schema version this binary reads and writes
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// ErrSchemaTooNew means the database was migrated by a newer binary, whose
+// records this one could corrupt
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+// MigrationResult is a migration that ran, or would run in a dry run
+type MigrationResult struct {
+	Version int
+	Name    string
+	Changed int // records rewritten
+}
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// Migrate applies the pending migrations, each in its own transaction. With
+// dryRun it runs them all in one transaction that is rolled back, to report
+// what would change.
+func Migrate(s *Store, dryRun bool) ([]MigrationResult, error) {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %q has version %d, want %d", m.Name, m.Version, i+1)
+		}
+	}
+
+	current, err := GetSchemaVersion(s)
+	if err != nil {
+		return nil, err
+	}
+	if current > SchemaVersion() {
+		return nil, fmt.Errorf("%w: database is at version %d, binary at %d", ErrSchemaTooNew, current, SchemaVersion())
+	}
+	pending := migrations[current:]
+
+	var results []MigrationResult
+	if dryRun {
+		err := s.update(func(tx *bbolt.Tx) error {
+			for _, m := range pending {
+				changed, err := m.Up(tx)
+				if err != nil {
+					return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+				}
+				results = append(results, MigrationResult{m.Version, m.Name, changed})
+			}
+			return errDryRun
+		})
+		if !errors.Is(err, errDryRun) {
+			return nil, err
+		}
+		return results, nil
+	}
+
+	for _, m := range pending {
+		changed := 0
+		err := s.update(func(tx *bbolt.Tx) error {
+			var err error
+			if changed, err = m.Up(tx); err != nil {
+				return err
+			}
+			return setSchemaVersionTx(tx, m.Version)
+		})
+		if err != nil {
+			return results, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		results = append(results, MigrationResult{m.Version, m.Name, changed})
+	}
+	return results, nil
+}
+
+// GetSchemaVersion is the version of the last migration applied to the
+// database, 0 for databases that predate migrations
+func GetSchemaVersion(s *Store) (int, error) {
+	version := 0
+	err := s.view(func(tx *bbolt.Tx) error {
+		var err error
+		version, err = schemaVersionTx(tx)
+		return err
+	})
+	return version, err
+}
+
+func schemaVersionTx(tx *bbolt.Tx) (int, error) {
+	meta := tx.Bucket(Buckets["meta"])
+	if meta == nil {
+		return 0, nil
+	}
+	v := meta.Get([]byte(schemaVersionKey))
+	if v == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(string(v))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q", v)
+	}
+	return version, nil
+}
+
+func setSchemaVersionTx(tx *bbolt.Tx, version int) error {
+	return tx.Bucket(Buckets["meta"]).Put([]byte(schemaVersionKey), []byte(strconv.Itoa(version)))
+}
@@ -1,12 +1,28 @@
 package models
 
+import "time"
+
+// Roles. Users have a global role; inside a class Class.Roles can make a
+// member professor or student regardless of it.
+const (
+	RoleStudent   = "student"
+	RoleProfessor = "professor"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
-	Username          string `json:"username"`
-	PasswordHashed    string `json:"password_hashed"`
-	PasswordNotHashed string `json:"password_now_hashed"`
-	FirstName         string `json:"first_name"`
-	LastName          string `json:"last_name"`
-	Role              string `json:"role"`
+	Username       string `json:"username"`
+	PasswordHashed string `json:"password_hashed"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	Role           string `json:"role"`
+	Disabled       bool   `json:"disabled,omitempty"`
+}
+
+type School struct {
+	InternalName string `json:"internal_name"`
+	Name         string `json:"name"`
+	TimeZone     string `json:"time_zone"` // IANA name, e.g. "America/La_Paz"
 }
 
 type Subject struct {
@@ -19,15 +35,47 @@ type Class struct {
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
 	Subject     string   `json:"subject"`
+	School      string   `json:"school,omitempty"` // empty means the default school
 	Users       []string `json:"users"`
+	// Roles overrides the role of members in this class, username -> role
+	Roles map[string]string `json:"roles,omitempty"`
+}
+
+// Late policy modes, an empty mode behaves like LatePolicyReject.
+const (
+	LatePolicyReject  = "reject"  // nothing is accepted after the due date
+	LatePolicyFlag    = "flag"    // accepted and marked as late
+	LatePolicyPenalty = "penalty" // accepted, the grade loses PenaltyPerDay% per late day
+)
+
+type LatePolicy struct {
+	Mode          string `json:"mode"`
+	PenaltyPerDay int    `json:"penalty_per_day"` // percentage, only for LatePolicyPenalty
+	GraceHours    int    `json:"grace_hours"`     // extra hours still counted as on time
+}
+
+type RubricLevel struct {
+	Name   string `json:"name"`
+	Points int    `json:"points"`
+}
+
+type RubricCriterion struct {
+	Name   string        `json:"name"`
+	Levels []RubricLevel `json:"levels"`
+}
+
+type Rubric struct {
+	Criteria []RubricCriterion `json:"criteria"`
 }
 
 type Assignment struct {
-	Id          int      `json:"id"`
-	Title       string   `json:"title"`
-	Description string   `json:"description"`
-	Content     []string `json:"content"`  // url to some file
-	DueDate     string   `json:"due_date"` // formatted "30/09/2025"
+	Id          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Content     []string   `json:"content"`  // url to some file
+	DueDate     time.Time  `json:"due_date"` // stored with the school's UTC offset
+	LatePolicy  LatePolicy `json:"late_policy"`
+	Rubric      *Rubric    `json:"rubric,omitempty"`
 }
 
 type Submission struct {
@@ -36,4 +84,47 @@ type Submission struct {
 	Content     []string `json:"content"`      // could be file path or text
 	SubmittedAt string   `json:"submitted_at"` // timestamp
 	Grade       string   `json:"grade,omitempty"`
+	Late        bool     `json:"late,omitempty"`
+	DaysLate    int      `json:"days_late,omitempty"`
+
+	// Written feedback, stored together with the grade
+	Feedback      string   `json:"feedback,omitempty"`
+	FeedbackFiles []string `json:"feedback_files,omitempty"` // urls to annotated files
+	GradedBy      string   `json:"graded_by,omitempty"`
+	GradedAt      string   `json:"graded_at,omitempty"`     // timestamp
+	RubricScores  []int    `json:"rubric_scores,omitempty"` // chosen level index per criterion
+}
+
+type Session struct {
+	Key       string    `json:"-"` // hash of the session id, safe to show and revoke by
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	CSRFToken string    `json:"csrf_token"`
+}
+
+type APIToken struct {
+	Key       string    `json:"-"` // hash of the token, safe to show and revoke by
+	Name      string    `json:"name"`
+	Username  string    `json:"username"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	LastUsed  time.Time `json:"last_used,omitempty"`
+}
+
+type PasswordReset struct {
+	Username  string    `json:"username"`
+	CreatedBy string    `json:"created_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type LoginAttempt struct {
+	Key         string    `json:"key"` // "user:<username>" or "ip:<address>"
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	Lockouts    int       `json:"lockouts"` // consecutive lockouts, drives the backoff
+	LockedUntil time.Time `json:"locked_until,omitempty"`
 }
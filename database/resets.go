@@ -0,0 +1,136 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"frontend/auth"
+	"frontend/database/models"
+	"slices"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// ResetTokenTTL is how long an admin issued reset link stays valid.
+var ResetTokenTTL = 24 * time.Hour
+
+// CreateResetToken issues a one-time password reset token for username. Like
+// session ids only the hash is stored, and older tokens of the user are
+// dropped so only the latest link works.
+func CreateResetToken(s *Store, username, createdBy string) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.update(func(tx *bbolt.Tx) error {
+		user, err := getTx[models.User](tx, Buckets["users"], username)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("user %s not found", username)
+		}
+
+		if err := deleteResetsTx(tx, func(r *models.PasswordReset) bool {
+			return r.Username == username
+		}); err != nil {
+			return err
+		}
+
+		return saveTx(tx, Buckets["resets"], SessionKey(token), models.PasswordReset{
+			Username:  username,
+			CreatedBy: createdBy,
+			ExpiresAt: time.Now().Add(ResetTokenTTL),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetResetToken returns the pending reset for token, nil when it is unknown or expired.
+func GetResetToken(s *Store, token string) (*models.PasswordReset, error) {
+	var reset *models.PasswordReset
+	err := s.view(func(tx *bbolt.Tx) error {
+		var err error
+		reset, err = getTx[models.PasswordReset](tx, Buckets["resets"], SessionKey(token))
+		return err
+	})
+	if err != nil || reset == nil || time.Now().After(reset.ExpiresAt) {
+		return nil, err
+	}
+	return reset, nil
+}
+
+// UseResetToken consumes token and sets the new password in one transaction,
+// so a token can never be used twice. It returns the user it belonged to.
+func UseResetToken(s *Store, token, plainPassword string) (string, error) {
+	hashed, err := auth.HashPassword(plainPassword)
+	if err != nil {
+		return "", err
+	}
+
+	var username string
+	err = s.update(func(tx *bbolt.Tx) error {
+		key := SessionKey(token)
+		reset, err := getTx[models.PasswordReset](tx, Buckets["resets"], key)
+		if err != nil {
+			return err
+		}
+		if reset == nil || time.Now().After(reset.ExpiresAt) {
+			return fmt.Errorf("reset token not found or expired")
+		}
+		if err := tx.Bucket(Buckets["resets"]).Delete([]byte(key)); err != nil {
+			return err
+		}
+
+		user, err := getTx[models.User](tx, Buckets["users"], reset.Username)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("user %s not found", reset.Username)
+		}
+		user.PasswordHashed = hashed
+		username = user.Username
+		return saveTx(tx, Buckets["users"], user.Username, user)
+	})
+	return username, err
+}
+
+// SweepResetTokens deletes reset tokens that expired before now.
+func SweepResetTokens(s *Store, now time.Time) error {
+	return s.update(func(tx *bbolt.Tx) error {
+		return deleteResetsTx(tx, func(r *models.PasswordReset) bool {
+			return now.After(r.ExpiresAt)
+		})
+	})
+}
+
+func deleteResetsTx(tx *bbolt.Tx, drop func(*models.PasswordReset) bool) error {
+	b := tx.Bucket(Buckets["resets"])
+	if b == nil {
+		return fmt.Errorf("bucket %s not found", Buckets["resets"])
+	}
+
+	var keys [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		var reset models.PasswordReset
+		if err := json.Unmarshal(v, &reset); err != nil || drop(&reset) {
+			keys = append(keys, slices.Clone(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
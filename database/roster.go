@@ -0,0 +1,151 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"frontend/auth"
+	"frontend/database/models"
+	"slices"
+	"strconv"
+
+	"go.etcd.io/bbolt"
+)
+
+// RosterRow is one line of a roster import.
+type RosterRow struct {
+	Line      int
+	Username  string
+	FirstName string
+	LastName  string
+	Role      string
+	ClassId   int
+}
+
+// RosterResult reports what the import did (or would do) with a row.
+type RosterResult struct {
+	Row         RosterRow
+	CreatedUser bool
+	Password    string // temporary password of created users, only after a real import
+	Enrolled    bool   // false when the user was already in the class
+	Err         string
+}
+
+// errRosterRollback aborts the import transaction without reporting a failure.
+var errRosterRollback = errors.New("roster import rolled back")
+
+// ImportRoster creates the missing users and enrolls every row in its class
+// inside a single transaction. If any row fails nothing is written; with
+// dryRun the transaction is always rolled back so the results are a preview.
+func ImportRoster(s *Store, rows []RosterRow, dryRun bool) ([]RosterResult, bool, error) {
+	results := make([]RosterResult, len(rows))
+
+	// Hash the temporary passwords before taking the write lock, bcrypt is slow
+	newUsers := map[string]*models.User{}
+	passwords := map[string]string{}
+	if !dryRun {
+		for _, row := range rows {
+			if _, seen := newUsers[row.Username]; seen || row.Username == "" || !slices.Contains(Roles, row.Role) {
+				continue
+			}
+			exists, err := Exists(s, Buckets["users"], row.Username)
+			if err != nil {
+				return nil, false, err
+			}
+			if exists {
+				continue
+			}
+
+			password, err := auth.RandomPassword(10)
+			if err != nil {
+				return nil, false, err
+			}
+			u, err := newUser(row.Username, password, row.FirstName, row.LastName, row.Role)
+			if err != nil {
+				return nil, false, err
+			}
+			newUsers[row.Username] = u
+			passwords[row.Username] = password
+		}
+	}
+
+	failed := false
+	err := s.update(func(tx *bbolt.Tx) error {
+		created := map[string]string{} // username -> role it was created with
+		for i, row := range rows {
+			results[i] = importRosterRow(tx, row, newUsers, passwords, created)
+			if results[i].Err != "" {
+				failed = true
+			}
+		}
+
+		if failed || dryRun {
+			return errRosterRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRosterRollback) {
+		return nil, false, err
+	}
+
+	committed := !failed && !dryRun
+	if !committed {
+		for i := range results {
+			results[i].Password = ""
+		}
+	}
+	return results, committed, nil
+}
+
+func importRosterRow(tx *bbolt.Tx, row RosterRow, newUsers map[string]*models.User, passwords map[string]string, created map[string]string) RosterResult {
+	result := RosterResult{Row: row}
+
+	if row.Username == "" {
+		result.Err = "falta el usuario"
+		return result
+	}
+	if !slices.Contains(Roles, row.Role) {
+		result.Err = fmt.Sprintf("rol inválido %q", row.Role)
+		return result
+	}
+
+	class, err := getTx[models.Class](tx, Buckets["classes"], strconv.Itoa(row.ClassId))
+	if err != nil || class == nil {
+		result.Err = fmt.Sprintf("la clase %d no existe", row.ClassId)
+		return result
+	}
+
+	user, err := getTx[models.User](tx, Buckets["users"], row.Username)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+
+	// In a dry run the user created by an earlier row isn't saved, so its
+	// role is checked against the one it was created with
+	if role, ok := created[row.Username]; ok && role != row.Role {
+		result.Err = fmt.Sprintf("el usuario ya existe con rol %q", role)
+		return result
+	}
+
+	if user == nil && created[row.Username] == "" {
+		// In a dry run there is no record to save, the row is only marked
+		result.CreatedUser = true
+		created[row.Username] = row.Role
+		if u, ok := newUsers[row.Username]; ok {
+			if err := saveTx(tx, Buckets["users"], u.Username, u); err != nil {
+				result.Err = err.Error()
+				return result
+			}
+			result.Password = passwords[row.Username]
+		}
+	} else if user != nil && user.Role != row.Role {
+		result.Err = fmt.Sprintf("el usuario ya existe con rol %q", user.Role)
+		return result
+	}
+
+	result.Enrolled = !slices.Contains(class.Users, row.Username)
+	if err := updateClassTx(tx, row.ClassId, addMember(row.Username)); err != nil {
+		result.Err = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"fmt"
+	"frontend/database/models"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"time"
+)
+
+// DefaultSchool is used for classes that were created without a school.
+const DefaultSchool = "default"
+
+// DefaultTimeZone is the zone the app used before schools had one.
+const DefaultTimeZone = "America/La_Paz"
+
+func CreateSchool(s *Store, internalName, name, timeZone string) error {
+	if _, err := time.LoadLocation(timeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+	}
+
+	return Save(s, Buckets["schools"], internalName, models.School{
+		InternalName: internalName,
+		Name:         name,
+		TimeZone:     timeZone,
+	})
+}
+
+// SchoolLocation returns the time zone of a school, falling back to the
+// default one when the school or its zone cannot be loaded.
+func SchoolLocation(s *Store, internalName string) *time.Location {
+	if internalName == "" {
+		internalName = DefaultSchool
+	}
+
+	timeZone := DefaultTimeZone
+	school, err := Get[models.School](s, Buckets["schools"], internalName)
+	if err == nil && school.TimeZone != "" {
+		timeZone = school.TimeZone
+	}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		slog.WarnContext(s.Context(), "cannot load school time zone", slog.String("time_zone", timeZone), telemetry.Err(err))
+		return time.UTC
+	}
+	return loc
+}
+
+// ClassLocation returns the time zone of the school a class belongs to.
+func ClassLocation(s *Store, classId int) *time.Location {
+	class, err := GetWithPrefix[models.Class](s, Buckets["classes"], fmt.Sprintf("%d", classId))
+	if err != nil {
+		return SchoolLocation(s, DefaultSchool)
+	}
+	return SchoolLocation(s, class.School)
+}
+
+// ensureDefaultSchool creates the default school, in timeZone, on new
+// databases and on those that predate schools.
+func ensureDefaultSchool(s *Store, timeZone string) error {
+	exists, err := Exists(s, Buckets["schools"], DefaultSchool)
+	if err != nil || exists {
+		return err
+	}
+	return CreateSchool(s, DefaultSchool, "Otero Ediciones", timeZone)
+}
@@ -2,28 +2,76 @@ package database
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"frontend/database/models"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"slices"
+	"time"
 
 	"go.etcd.io/bbolt"
 )
 
-// GenerateSession creates a secure random session ID and stores mapping sessionID -> username
-func GenerateSession(s *Store, username string) (string, error) {
-	// 32 random bytes = 64 hex characters
+var (
+	// SessionMaxAge is how long a session lives after login, no matter the activity
+	SessionMaxAge = 7 * 24 * time.Hour
+	// SessionIdleTimeout ends sessions that have not been used for a while
+	SessionIdleTimeout = 2 * time.Hour
+
+	// last seen is only written once per interval to avoid a write on every request
+	sessionTouchEvery = time.Minute
+)
+
+var ErrSessionExpired = errors.New("session expired")
+
+// SessionKey is the bucket key of a session id. Only the hash is stored so a
+// copy of the database can't be used to hijack sessions.
+func SessionKey(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:])
+}
+
+func sessionExpired(session *models.Session, now time.Time) bool {
+	return now.Sub(session.CreatedAt) > SessionMaxAge || now.Sub(session.LastSeen) > SessionIdleTimeout
+}
+
+// newToken returns 32 secure random bytes as 64 hex characters
+func newToken() (string, error) {
 	b := make([]byte, 32)
-	_, err := rand.Read(b)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateSession creates a secure random session ID and stores it with the
+// user, creation time, client it was opened from and its CSRF token
+func GenerateSession(s *Store, username, userAgent, ip string) (string, error) {
+	sessionID, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	csrfToken, err := newToken()
 	if err != nil {
 		return "", err
 	}
-	sessionID := hex.EncodeToString(b)
 
-	err = s.db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket(Buckets["sessions"])
-		if b == nil {
-			return fmt.Errorf("sessions bucket not found")
-		}
-		return b.Put([]byte(sessionID), []byte(username))
+	now := time.Now()
+	session := models.Session{
+		Username:  username,
+		CreatedAt: now,
+		LastSeen:  now,
+		UserAgent: userAgent,
+		IP:        ip,
+		CSRFToken: csrfToken,
+	}
+
+	err = s.update(func(tx *bbolt.Tx) error {
+		return saveTx(tx, Buckets["sessions"], SessionKey(sessionID), session)
 	})
 	if err != nil {
 		return "", err
@@ -32,25 +80,174 @@ func GenerateSession(s *Store, username string) (string, error) {
 	return sessionID, nil
 }
 
-// GetUserFromSession resolves username from session ID
-func GetUserFromSession(s *Store, sessionID string) (string, error) {
-	var user string
-	err := s.db.View(func(tx *bbolt.Tx) error {
+// GetSession resolves a session ID. Expired sessions are deleted and reported
+// as ErrSessionExpired.
+func GetSession(s *Store, sessionID string) (*models.Session, error) {
+	key := SessionKey(sessionID)
+	now := time.Now()
+
+	var session *models.Session
+	err := s.view(func(tx *bbolt.Tx) error {
+		var err error
+		session, err = getTx[models.Session](tx, Buckets["sessions"], key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, fmt.Errorf("session not found")
+	}
+
+	if sessionExpired(session, now) {
+		_ = Delete(s, Buckets["sessions"], key)
+		return nil, ErrSessionExpired
+	}
+
+	// Sessions opened before CSRF tokens existed get one on first use
+	changed := false
+	if session.CSRFToken == "" {
+		if session.CSRFToken, err = newToken(); err != nil {
+			return nil, err
+		}
+		changed = true
+	}
+	if now.Sub(session.LastSeen) > sessionTouchEvery {
+		session.LastSeen = now
+		changed = true
+	}
+	if changed {
+		if err := Save(s, Buckets["sessions"], key, session); err != nil {
+			slog.WarnContext(s.Context(), "could not update session", telemetry.Err(err))
+		}
+	}
+
+	return session, nil
+}
+
+func DeleteSession(s *Store, sessionID string) error {
+	err := Delete(s, Buckets["sessions"], SessionKey(sessionID))
+	return err
+}
+
+// ListUserSessions returns the active sessions of a user, most recently used first
+func ListUserSessions(s *Store, username string) ([]*models.Session, error) {
+	now := time.Now()
+	sessions := []*models.Session{}
+
+	err := s.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(Buckets["sessions"])
 		if b == nil {
 			return fmt.Errorf("sessions bucket not found")
 		}
-		v := b.Get([]byte(sessionID))
-		if v == nil {
+		return b.ForEach(func(k, v []byte) error {
+			var session models.Session
+			if err := json.Unmarshal(v, &session); err != nil {
+				return nil // legacy or corrupt entry, the sweeper removes it
+			}
+			if session.Username != username || sessionExpired(&session, now) {
+				return nil
+			}
+			session.Key = string(k)
+			sessions = append(sessions, &session)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(sessions, func(a, b *models.Session) int {
+		return b.LastSeen.Compare(a.LastSeen)
+	})
+	return sessions, nil
+}
+
+// RevokeSession deletes one session by key, only if it belongs to username
+func RevokeSession(s *Store, username, key string) error {
+	return s.update(func(tx *bbolt.Tx) error {
+		session, err := getTx[models.Session](tx, Buckets["sessions"], key)
+		if err != nil {
+			return err
+		}
+		if session == nil || session.Username != username {
 			return fmt.Errorf("session not found")
 		}
-		user = string(v)
-		return nil
+		return tx.Bucket(Buckets["sessions"]).Delete([]byte(key))
 	})
-	return user, err
 }
 
-func DeleteSession(s *Store, sessionID string) error {
-	err := Delete(s, Buckets["sessions"], sessionID)
-	return err
+// RevokeUserSessions deletes every session of a user except keepID, which may
+// be empty to log the user out everywhere. It returns how many were removed.
+func RevokeUserSessions(s *Store, username, keepID string) (int, error) {
+	keep := ""
+	if keepID != "" {
+		keep = SessionKey(keepID)
+	}
+
+	return deleteSessions(s, func(key string, session *models.Session) bool {
+		return session.Username == username && key != keep
+	})
+}
+
+// CountActiveSessions counts the sessions that haven't expired at now
+func CountActiveSessions(s *Store, now time.Time) (int, error) {
+	count := 0
+	err := s.view(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(Buckets["sessions"])
+		if b == nil {
+			return fmt.Errorf("sessions bucket not found")
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var session models.Session
+			if json.Unmarshal(v, &session) == nil && !sessionExpired(&session, now) {
+				count++
+			}
+			return nil
+		})
+	})
+	return count, err
+}
+
+// SweepSessions deletes expired sessions and entries that no longer parse
+func SweepSessions(s *Store, now time.Time) (int, error) {
+	return deleteSessions(s, func(_ string, session *models.Session) bool {
+		return session == nil || sessionExpired(session, now)
+	})
+}
+
+// deleteSessions removes the sessions matched by drop; session is nil for
+// entries that can't be decoded.
+func deleteSessions(s *Store, drop func(key string, session *models.Session) bool) (int, error) {
+	removed := 0
+	err := s.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(Buckets["sessions"])
+		if b == nil {
+			return fmt.Errorf("sessions bucket not found")
+		}
+
+		var keys [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var session *models.Session
+			if err := json.Unmarshal(v, &session); err != nil {
+				session = nil
+			}
+			if drop(string(k), session) {
+				keys = append(keys, slices.Clone(k))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		removed = len(keys)
+		return nil
+	})
+	return removed, err
 }
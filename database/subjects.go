@@ -9,3 +9,7 @@ func CreateSubject(s *Store, internalName, name string) error {
 		InternalName: internalName,
 		Name:         name})
 }
+
+func ListSubjects(s *Store) ([]*models.Subject, error) {
+	return List[models.Subject](s, Buckets["subjects"])
+}
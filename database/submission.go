@@ -1,82 +1,108 @@
 package database
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"frontend/database/models"
 	"strconv"
 	"strings"
-
-	"go.etcd.io/bbolt"
 )
 
-// CreateSubmission stores a submission with unique (classId, assignmentId, username).
-func CreateSubmission(
-	s *Store,
-	classId, assignmentId int,
-	username, description string,
-	content []string,
-	submittedAt, grade string,
-) (*models.Submission, error) {
-	var sub *models.Submission
+// GetSubmission retrieves submission by assignmentId + studentId
+func GetSubmission(s *Store, classId, assignmentId int, username string) (*models.Submission, error) {
+	key := fmt.Sprintf("%d:%d:%s", classId, assignmentId, username)
+	return Get[models.Submission](s, Buckets["submissions"], key)
+}
 
-	err := s.db.Update(func(tx *bbolt.Tx) error {
-		b, err := tx.CreateBucketIfNotExists(Buckets["submissions"])
-		if err != nil {
-			return err
-		}
+// Grading is everything a professor sets when grading a submission.
+type Grading struct {
+	Grade         string
+	Feedback      string
+	FeedbackFiles []string // nil keeps the files already attached
+	RubricScores  []int    // nil when the assignment has no rubric
+	GradedBy      string
+	GradedAt      string
+}
 
-		// Composite key: classId:assignmentId:username
-		key := fmt.Sprintf("%d:%d:%s", classId, assignmentId, username)
+// MaxGrade is the top of the grade scale; rubric grades are scaled to it
+const MaxGrade = 100
 
-		sub = &models.Submission{
-			Username:    username,
-			Description: description,
-			Content:     content,
-			SubmittedAt: submittedAt,
-			Grade:       grade,
-		}
+// ErrInvalidGrade is returned for a grade that isn't a whole number from 0
+// to MaxGrade
+var ErrInvalidGrade = errors.New("invalid grade")
 
-		data, err := json.Marshal(sub)
+// CheckGrade returns ErrInvalidGrade unless grade is a whole number from 0 to
+// MaxGrade. GradeSubmission checks it too, handlers call it first to fail
+// before storing any file.
+func CheckGrade(grade string) error {
+	g, err := strconv.Atoi(grade)
+	if err != nil || g < 0 || g > MaxGrade {
+		return fmt.Errorf("%w %q, want 0 to %d", ErrInvalidGrade, grade, MaxGrade)
+	}
+	return nil
+}
+
+// GradeSubmission → updates the grade together with the feedback and who
+// graded it, in one transaction so a turn in made meanwhile isn't lost
+func GradeSubmission(s *Store, classId, assignmentId int, username string, g Grading) (*models.Submission, error) {
+	grade := g.Grade
+	if err := CheckGrade(grade); err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%d:%d:%s", classId, assignmentId, username)
+
+	var sub *models.Submission
+	err := s.Tx(func(tx *Tx) error {
+		var err error
+		sub, err = TxGet[models.Submission](tx, Buckets["submissions"], key)
 		if err != nil {
 			return err
 		}
 
-		return b.Put([]byte(key), data)
-	})
+		sub.Grade = grade
+		sub.Feedback = g.Feedback
+		if g.FeedbackFiles != nil {
+			sub.FeedbackFiles = g.FeedbackFiles
+		}
+		sub.RubricScores = g.RubricScores
+		sub.GradedBy = g.GradedBy
+		sub.GradedAt = g.GradedAt
 
+		return TxSave(tx, Buckets["submissions"], key, sub)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return sub, nil
 }
 
-// GetSubmission retrieves submission by assignmentId + studentId
-func GetSubmission(s *Store, classId, assignmentId int, username string) (*models.Submission, error) {
+// UpdateSubmission applies updater to the submission of username, starting
+// from an empty one on the first turn in, and saves it in the same
+// transaction. The grade and feedback set meanwhile by a professor are kept.
+func UpdateSubmission(s *Store, classId, assignmentId int, username string, updater func(*models.Submission) error) (*models.Submission, error) {
 	key := fmt.Sprintf("%d:%d:%s", classId, assignmentId, username)
-	return Get[models.Submission](s, Buckets["submissions"], key)
-}
 
-// GradeSubmission → updates the Grade field
-func GradeSubmission(s *Store, classId, assignmentId int, username, grade string) (*models.Submission, error) {
-	_, err := strconv.Atoi(grade)
-	if err != nil {
-		fmt.Println("Invalid grade: %w", err)
-		return nil, err
-	}
-
-	key := fmt.Sprintf("%d:%d:%s", classId, assignmentId, username)
+	var sub *models.Submission
+	err := s.Tx(func(tx *Tx) error {
+		var err error
+		sub, err = TxGet[models.Submission](tx, Buckets["submissions"], key)
+		if errors.Is(err, ErrNotFound) {
+			sub, err = &models.Submission{Username: username, Content: []string{}}, nil
+		}
+		if err != nil {
+			return err
+		}
 
-	sub, err := Get[models.Submission](s, Buckets["submissions"], key)
+		if err := updater(sub); err != nil {
+			return err
+		}
+		return TxSave(tx, Buckets["submissions"], key, sub)
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	sub.Grade = grade
-
-	err = Save(s, Buckets["submissions"], key, sub)
-
-	return sub, err
+	return sub, nil
 }
 
 func GetSubmissionsByAssignment(s *Store, classId, assignmentId int) ([]*models.Submission, error) {
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"errors"
+	"frontend/database/models"
+	"path/filepath"
+	"testing"
+)
+
+func TestGradeSubmissionRange(t *testing.T) {
+	s, _, err := Open(filepath.Join(t.TempDir(), "grades.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	if err := Save(s, Buckets["submissions"], "1:1:student1", models.Submission{Username: "student1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		grade string
+		ok    bool
+	}{
+		{"0", true},
+		{"75", true},
+		{"100", true},
+		{"-5", false},
+		{"101", false},
+		{"1000", false},
+		{"", false},
+		{"9.5", false},
+		{"diez", false},
+	} {
+		_, err := GradeSubmission(s, 1, 1, "student1", Grading{Grade: tt.grade})
+		if tt.ok && err != nil {
+			t.Errorf("grade %q: %v", tt.grade, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidGrade) {
+			t.Errorf("grade %q: got %v, want ErrInvalidGrade", tt.grade, err)
+		}
+	}
+
+	sub, err := GetSubmission(s, 1, 1, "student1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Grade != "100" {
+		t.Errorf("stored grade %q, want the last valid one, 100", sub.Grade)
+	}
+}
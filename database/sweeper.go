@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"time"
+)
+
+// StartSweeper removes expired sessions, password reset tokens, API tokens
+// and stale login counters every interval until ctx is done
+func StartSweeper(ctx context.Context, s *Store, every time.Duration) {
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				sweep(s, now)
+			}
+		}
+	}()
+}
+
+func sweep(s *Store, now time.Time) {
+	removed, err := SweepSessions(s, now)
+	if err != nil {
+		slog.Warn("session sweep failed", telemetry.Err(err))
+	} else if removed > 0 {
+		slog.Info("removed expired sessions", slog.Int("count", removed))
+	}
+
+	if err := SweepResetTokens(s, now); err != nil {
+		slog.Warn("reset token sweep failed", telemetry.Err(err))
+	}
+
+	if err := SweepLoginAttempts(s, now); err != nil {
+		slog.Warn("login attempt sweep failed", telemetry.Err(err))
+	}
+
+	if err := SweepAPITokens(s, now); err != nil {
+		slog.Warn("API token sweep failed", telemetry.Err(err))
+	}
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"frontend/database/models"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// APITokenPrefix starts every personal API token, which tells them apart
+// from session ids and makes leaked tokens easy to grep for.
+const APITokenPrefix = "pat_"
+
+// APITokenMaxTTL is the longest lifetime a personal API token can have.
+var APITokenMaxTTL = 365 * 24 * time.Hour
+
+// IsAPIToken reports whether token looks like a personal API token.
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// CreateAPIToken issues a personal API token for username. The token is only
+// returned here; like session ids just its hash is stored.
+func CreateAPIToken(s *Store, username, name string, scopes []string, ttl time.Duration) (string, error) {
+	if ttl <= 0 || ttl > APITokenMaxTTL {
+		return "", fmt.Errorf("invalid token lifetime %s", ttl)
+	}
+
+	random, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	token := APITokenPrefix + random
+
+	now := time.Now()
+	err = s.update(func(tx *bbolt.Tx) error {
+		return saveTx(tx, Buckets["tokens"], SessionKey(token), models.APIToken{
+			Name:      name,
+			Username:  username,
+			Scopes:    scopes,
+			CreatedAt: now,
+			ExpiresAt: now.Add(ttl),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetAPIToken resolves a personal API token. Expired tokens are deleted and
+// reported as not found.
+func GetAPIToken(s *Store, token string) (*models.APIToken, error) {
+	key := SessionKey(token)
+	now := time.Now()
+
+	var t *models.APIToken
+	err := s.view(func(tx *bbolt.Tx) error {
+		var err error
+		t, err = getTx[models.APIToken](tx, Buckets["tokens"], key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("token not found")
+	}
+
+	if now.After(t.ExpiresAt) {
+		_ = Delete(s, Buckets["tokens"], key)
+		return nil, fmt.Errorf("token not found")
+	}
+
+	if now.Sub(t.LastUsed) > sessionTouchEvery {
+		t.LastUsed = now
+		if err := Save(s, Buckets["tokens"], key, t); err != nil {
+			slog.WarnContext(s.Context(), "could not update API token", telemetry.Err(err))
+		}
+	}
+
+	t.Key = key
+	return t, nil
+}
+
+// DeleteAPIToken deletes a token by its plain value
+func DeleteAPIToken(s *Store, token string) error {
+	return Delete(s, Buckets["tokens"], SessionKey(token))
+}
+
+// ListUserAPITokens returns the live tokens of a user, newest first
+func ListUserAPITokens(s *Store, username string) ([]*models.APIToken, error) {
+	now := time.Now()
+	tokens := []*models.APIToken{}
+
+	err := s.view(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(Buckets["tokens"])
+		if b == nil {
+			return fmt.Errorf("tokens bucket not found")
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var t models.APIToken
+			if err := json.Unmarshal(v, &t); err != nil {
+				return nil // corrupt entry, the sweeper removes it
+			}
+			if t.Username != username || now.After(t.ExpiresAt) {
+				return nil
+			}
+			t.Key = string(k)
+			tokens = append(tokens, &t)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(tokens, func(a, b *models.APIToken) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return tokens, nil
+}
+
+// RevokeAPIToken deletes one token by key, only if it belongs to username
+func RevokeAPIToken(s *Store, username, key string) error {
+	return s.update(func(tx *bbolt.Tx) error {
+		t, err := getTx[models.APIToken](tx, Buckets["tokens"], key)
+		if err != nil {
+			return err
+		}
+		if t == nil || t.Username != username {
+			return fmt.Errorf("token not found")
+		}
+		return tx.Bucket(Buckets["tokens"]).Delete([]byte(key))
+	})
+}
+
+// SweepAPITokens deletes tokens that expired before now and entries that no longer parse
+func SweepAPITokens(s *Store, now time.Time) error {
+	return s.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(Buckets["tokens"])
+		if b == nil {
+			return fmt.Errorf("tokens bucket not found")
+		}
+
+		var keys [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var t models.APIToken
+			if err := json.Unmarshal(v, &t); err != nil || now.After(t.ExpiresAt) {
+				keys = append(keys, slices.Clone(k))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// ErrNotFound is returned by TxGet, and the operations built on it, for a
+// key that isn't there
+var ErrNotFound = errors.New("not found")
+
+// Tx is a unit of work: the reads and writes made through it see each other
+// and are committed, or dropped, together. Use it with the Tx* functions,
+// which mirror Get, Save, Delete and ListByPrefix.
+type Tx struct {
+	tx *bbolt.Tx
+}
+
+// Tx runs fn in one write transaction, committed when fn returns nil and
+// rolled back otherwise. Keep slow work such as uploads out of fn, the
+// database takes one writer at a time.
+func (s *Store) Tx(fn func(tx *Tx) error) error {
+	return s.traced("bbolt.Update", s.db.Update, func(tx *bbolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+// View runs fn in one read transaction, a consistent view of the database
+func (s *Store) View(fn func(tx *Tx) error) error {
+	return s.traced("bbolt.View", s.db.View, func(tx *bbolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+// TxGet reads and decodes key, wrapping ErrNotFound when it is missing
+func TxGet[T any](tx *Tx, bucket []byte, key string) (*T, error) {
+	out, err := getTx[T](tx.tx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, fmt.Errorf("key %s: %w", key, ErrNotFound)
+	}
+	return out, nil
+}
+
+// TxSave encodes and writes value under key
+func TxSave[T any](tx *Tx, bucket []byte, key string, value T) error {
+	return saveTx(tx.tx, bucket, key, value)
+}
+
+// TxDelete removes key, missing keys are not an error
+func TxDelete(tx *Tx, bucket []byte, key string) error {
+	b := tx.tx.Bucket(bucket)
+	if b == nil {
+		return fmt.Errorf("bucket %s not found", bucket)
+	}
+	return b.Delete([]byte(key))
+}
+
+// TxListByPrefix decodes the records whose key starts with the prefixes
+// joined by ":", plus a trailing ":"
+func TxListByPrefix[T any](tx *Tx, bucket []byte, prefixes ...string) ([]*T, error) {
+	var results []*T
+	err := forPrefix(tx.tx, bucket, prefixes, func(k, v []byte) error {
+		var out T
+		if err := json.Unmarshal(v, &out); err != nil {
+			return err
+		}
+		results = append(results, &out)
+		return nil
+	})
+	return results, err
+}
+
+// TxDeleteByPrefix removes the records TxListByPrefix would return, and
+// returns how many there were
+func TxDeleteByPrefix(tx *Tx, bucket []byte, prefixes ...string) (int, error) {
+	var keys [][]byte
+	err := forPrefix(tx.tx, bucket, prefixes, func(k, v []byte) error {
+		keys = append(keys, bytes.Clone(k))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Deleting under a live cursor skips keys, so collect them first
+	b := tx.tx.Bucket(bucket)
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+func forPrefix(tx *bbolt.Tx, bucket []byte, prefixes []string, fn func(k, v []byte) error) error {
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return fmt.Errorf("bucket %s not found", bucket)
+	}
+
+	p := []byte(strings.Join(prefixes, ":") + ":")
+	c := b.Cursor()
+	for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
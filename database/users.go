@@ -1,29 +1,96 @@
 package database
 
 import (
+	"fmt"
 	"frontend/auth"
 	"frontend/database/models"
+	"regexp"
+	"slices"
+	"strings"
 )
 
-// CreateUser stores a new user with hashing + encryption
-func CreateUser(s *Store, username, plainPassword, firstName, lastName, role string, encKey []byte) error {
-	hashed, err := auth.HashPassword(plainPassword)
+// ValidName restricts usernames and subject identifiers to URL friendly characters.
+var ValidName = regexp.MustCompile(`^[a-zA-Z0-9_.\-]+$`)
+
+// Roles a user can have.
+var Roles = []string{models.RoleStudent, models.RoleProfessor, models.RoleAdmin}
+
+// ClassRoles are the roles a member can have inside a class.
+var ClassRoles = []string{models.RoleStudent, models.RoleProfessor}
+
+// CreateUser stores a new user with a hashed password
+func CreateUser(s *Store, username, plainPassword, firstName, lastName, role string) error {
+	u, err := newUser(username, plainPassword, firstName, lastName, role)
 	if err != nil {
 		return err
 	}
-	encrypted, err := auth.Encrypt(encKey, plainPassword)
+
+	return Save(s, Buckets["users"], u.Username, u)
+}
+
+// newUser builds a user record, hashing its password.
+func newUser(username, plainPassword, firstName, lastName, role string) (*models.User, error) {
+	hashed, err := auth.HashPassword(plainPassword)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return &models.User{
+		Username:       username,
+		PasswordHashed: hashed,
+		FirstName:      firstName,
+		LastName:       lastName,
+		Role:           role,
+	}, nil
+}
+
+func ListUsers(s *Store) ([]*models.User, error) {
+	users, err := List[models.User](s, Buckets["users"])
+	if err != nil {
+		return nil, err
 	}
+	slices.SortFunc(users, func(a, b *models.User) int {
+		return strings.Compare(a.Username, b.Username)
+	})
+	return users, nil
+}
 
-	u := models.User{
-		Username:          username,
-		PasswordHashed:    hashed,
-		PasswordNotHashed: encrypted,
-		FirstName:         firstName,
-		LastName:          lastName,
-		Role:              role,
+// UpdateUser changes the profile fields of an existing user.
+func UpdateUser(s *Store, username, firstName, lastName, role string) (*models.User, error) {
+	if !slices.Contains(Roles, role) {
+		return nil, fmt.Errorf("invalid role %q", role)
 	}
+	return updateUser(s, username, func(u *models.User) {
+		u.FirstName = firstName
+		u.LastName = lastName
+		u.Role = role
+	})
+}
 
-	return Save(s, Buckets["users"], u.Username, u)
+// SetUserDisabled deactivates (or reactivates) a user, disabled users cannot log in.
+func SetUserDisabled(s *Store, username string, disabled bool) (*models.User, error) {
+	return updateUser(s, username, func(u *models.User) {
+		u.Disabled = disabled
+	})
+}
+
+// SetPassword replaces the password of a user with a fresh hash.
+func SetPassword(s *Store, username, plainPassword string) error {
+	hashed, err := auth.HashPassword(plainPassword)
+	if err != nil {
+		return err
+	}
+	_, err = updateUser(s, username, func(u *models.User) {
+		u.PasswordHashed = hashed
+	})
+	return err
+}
+
+func updateUser(s *Store, username string, updater func(*models.User)) (*models.User, error) {
+	u, err := Get[models.User](s, Buckets["users"], username)
+	if err != nil {
+		return nil, err
+	}
+	updater(u)
+	return u, Save(s, Buckets["users"], username, u)
 }
@@ -2,15 +2,70 @@ package database
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"frontend/internal/telemetry"
+	"runtime"
 	"strings"
+	"time"
 
 	"go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Store struct {
-	db *bbolt.DB
+	db  *bbolt.DB
+	ctx context.Context // parent of the spans and logs of this store, see WithContext
+}
+
+// WithContext returns the same database bound to ctx, so its transactions
+// are traced as part of the request ctx belongs to.
+func (s *Store) WithContext(ctx context.Context) *Store {
+	return &Store{db: s.db, ctx: ctx}
+}
+
+// Context is the context the store is bound to, Background when unbound
+func (s *Store) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// view runs fn in a read transaction inside a span
+func (s *Store) view(fn func(*bbolt.Tx) error) error {
+	return s.traced("bbolt.View", s.db.View, fn)
+}
+
+// update runs fn in a write transaction inside a span
+func (s *Store) update(fn func(*bbolt.Tx) error) error {
+	return s.traced("bbolt.Update", s.db.Update, fn)
+}
+
+func (s *Store) traced(name string, run func(func(*bbolt.Tx) error) error, fn func(*bbolt.Tx) error) error {
+	operation := callerName()
+	_, span := telemetry.Start(s.Context(), name,
+		attribute.String("db.system", "bbolt"),
+		attribute.String("db.operation.name", operation),
+	)
+	start := time.Now()
+	err := run(fn)
+	telemetry.ObserveTx(strings.ToLower(strings.TrimPrefix(name, "bbolt.")), operation, time.Since(start))
+	telemetry.End(span, err)
+	return err
+}
+
+// callerName is the database function that opened the transaction
+func callerName() string {
+	pc, _, _, ok := runtime.Caller(3)
+	if !ok {
+		return "unknown"
+	}
+	name := runtime.FuncForPC(pc).Name()
+	name = strings.TrimPrefix(name, "frontend/database.")
+	// Generic helpers report as Get[...]
+	return strings.TrimSuffix(name, "[...]")
 }
 
 func New(path, bucketName string) (*Store, error) {
@@ -34,8 +89,18 @@ func (s *Store) Close() {
 	s.db.Close()
 }
 
+// Size is the size of the database file in bytes
+func (s *Store) Size() (int64, error) {
+	var size int64
+	err := s.view(func(tx *bbolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	return size, err
+}
+
 func Save[T any](s *Store, bucket []byte, key string, value T) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.update(func(tx *bbolt.Tx) error {
 		b, err := tx.CreateBucketIfNotExists(bucket)
 		if err != nil {
 			return err
@@ -50,7 +115,7 @@ func Save[T any](s *Store, bucket []byte, key string, value T) error {
 
 func Get[T any](s *Store, bucket []byte, key string) (*T, error) {
 	var out T
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(bucket)
 		if b == nil {
 			return fmt.Errorf("bucket %s not found", bucket)
@@ -70,7 +135,7 @@ func Get[T any](s *Store, bucket []byte, key string) (*T, error) {
 func GetWithPrefix[T any](s *Store, bucket []byte, id string, prefixes ...string) (*T, error) {
 	var result *T
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(bucket)
 		if b == nil {
 			return fmt.Errorf("bucket %s not found", bucket)
@@ -79,7 +144,6 @@ func GetWithPrefix[T any](s *Store, bucket []byte, id string, prefixes ...string
 		// build key: prefix1:prefix2:...:id
 		parts := append(prefixes, id)
 		key := []byte(strings.Join(parts, ":"))
-		fmt.Printf("🔑 [GetWithPrefix] looking for key=%q\n", key)
 
 		v := b.Get(key)
 		if v == nil {
@@ -99,7 +163,7 @@ func GetWithPrefix[T any](s *Store, bucket []byte, id string, prefixes ...string
 
 func Exists(s *Store, bucket []byte, key string) (bool, error) {
 	var found bool
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(bucket)
 		if b == nil {
 			return fmt.Errorf("bucket %s not found", bucket)
@@ -113,7 +177,7 @@ func Exists(s *Store, bucket []byte, key string) (bool, error) {
 
 func ExistsWithPrefix(s *Store, bucket []byte, prefixes ...string) bool {
 	var found bool
-	_ = s.db.View(func(tx *bbolt.Tx) error {
+	_ = s.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(bucket)
 		if b == nil {
 			return fmt.Errorf("bucket %s not found", bucket)
@@ -134,7 +198,7 @@ func ExistsWithPrefix(s *Store, bucket []byte, prefixes ...string) bool {
 
 func List[T any](s *Store, bucketName []byte) ([]*T, error) {
 	var out []*T
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(bucketName)
 		if b == nil {
 			return fmt.Errorf("bucket %s not found", bucketName)
@@ -155,7 +219,7 @@ func List[T any](s *Store, bucketName []byte) ([]*T, error) {
 func ListByPrefix[T any](s *Store, bucket []byte, prefixes ...string) ([]*T, error) {
 	var results []*T
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(bucket)
 		if b == nil {
 			return fmt.Errorf("bucket %s not found", bucket)
@@ -182,8 +246,38 @@ func ListByPrefix[T any](s *Store, bucket []byte, prefixes ...string) ([]*T, err
 	return results, err
 }
 
+// getTx reads and decodes key inside an open transaction, nil when missing.
+func getTx[T any](tx *bbolt.Tx, bucket []byte, key string) (*T, error) {
+	b := tx.Bucket(bucket)
+	if b == nil {
+		return nil, fmt.Errorf("bucket %s not found", bucket)
+	}
+	v := b.Get([]byte(key))
+	if v == nil {
+		return nil, nil
+	}
+	var out T
+	if err := json.Unmarshal(v, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// saveTx encodes and writes value inside an open transaction.
+func saveTx[T any](tx *bbolt.Tx, bucket []byte, key string, value T) error {
+	b, err := tx.CreateBucketIfNotExists(bucket)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), data)
+}
+
 func Delete(s *Store, bucketName []byte, key string) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(bucketName)
 		if b == nil {
 			return fmt.Errorf("bucket %s not found", bucketName)
@@ -1,7 +1,6 @@
 package helper
 
 import (
-	"fmt"
 	"slices"
 )
 
@@ -34,10 +33,3 @@ func First[T any](v []*T) *T {
 	}
 	return v[0]
 }
-
-// PrintArray prints all elements of any slice type.
-func PrintArray[T any](arr []T) {
-	for i, v := range arr {
-		fmt.Printf("Index %d: %v\n", i, v)
-	}
-}
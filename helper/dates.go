@@ -1,84 +1,99 @@
 package helper
 
 import (
-	"fmt"
 	"frontend/database/models"
 	"sort"
 	"time"
 )
 
+// DueDateLayout is how due dates are shown and typed in the forms.
+const DueDateLayout = "02/01/2006 15:04"
+
 // DateStatus represents how far we are from a due date.
 type DateStatus struct {
-	Past     bool // true if due date already passed (after end of that day)
-	DaysLeft int  // number of full days left (negative if past)
+	Past     bool // true if the due date and time already passed
+	DaysLeft int  // number of calendar days left (negative if past)
 }
 
-// GetDateStatus returns whether a date has passed and how many days remain.
-func GetDateStatus(dueDate string) (DateStatus, error) {
-	loc, err := time.LoadLocation("America/La_Paz")
-	if err != nil {
-		return DateStatus{}, err
-	}
-
-	due, err := time.ParseInLocation("02/01/2006", dueDate, loc)
-	if err != nil {
-		return DateStatus{}, err
-	}
-
+// GetDateStatus returns whether a due date has passed and how many days
+// remain, counting calendar days in the due date's own time zone.
+func GetDateStatus(dueDate time.Time) DateStatus {
+	loc := dueDate.Location()
 	now := time.Now().In(loc)
 
-	// compare using whole days
-	dueDateOnly := due.Truncate(24 * time.Hour)
-	today := now.Truncate(24 * time.Hour)
+	// compare using whole calendar days
+	dueDay := time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day(), 0, 0, 0, 0, loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 
-	daysLeft := int(dueDateOnly.Sub(today).Hours() / 24)
-	past := today.After(dueDateOnly)
+	daysLeft := int(dueDay.Sub(today).Round(time.Hour).Hours() / 24)
+	past := now.After(dueDate)
 
-	return DateStatus{Past: past, DaysLeft: daysLeft}, nil
+	return DateStatus{Past: past, DaysLeft: daysLeft}
 }
 
-// OrderAssignments sorts assignments in-place from older to newer due dates.
-func OrderAssignments(assignments []*models.Assignment) ([]*models.Assignment, error) {
-	loc, err := time.LoadLocation("America/La_Paz")
-	if err != nil {
-		return nil, fmt.Errorf("error loading location: %w", err)
+// ParseDueDate reads a due date typed in a form as wall time in loc.
+// A date without time means the end of that day.
+func ParseDueDate(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range []string{DueDateLayout, "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
 	}
 
-	layout := "02/01/2006"
+	var err error
+	for _, layout := range []string{"02/01/2006", "2006-01-02"} {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, value, loc); err == nil {
+			return EndOfDay(t), nil
+		}
+	}
+	return time.Time{}, err
+}
 
-	sort.Slice(assignments, func(i, j int) bool {
-		dateI, errI := time.ParseInLocation(layout, assignments[i].DueDate, loc)
-		dateJ, errJ := time.ParseInLocation(layout, assignments[j].DueDate, loc)
+// EndOfDay returns the last minute of t's day, in t's time zone.
+func EndOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 0, 0, t.Location())
+}
 
-		// handle invalid dates gracefully
-		if errI != nil || errJ != nil {
-			return assignments[i].DueDate > assignments[j].DueDate
-		}
+// FormatDueDate renders a due date as "dd/mm/yyyy hh:mm" in its own time zone.
+func FormatDueDate(t time.Time) string {
+	if t.IsZero() {
+		return "–"
+	}
+	return t.Format(DueDateLayout)
+}
 
-		return dateI.After(dateJ)
+// FormatTimestamp renders an RFC3339 timestamp as "dd/mm/yyyy hh:mm" using
+// the offset it was stored with. Values that cannot be parsed are returned
+// untouched.
+func FormatTimestamp(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.Format(DueDateLayout)
+}
+
+// OrderAssignments sorts assignments in-place from newer to older due dates.
+func OrderAssignments(assignments []*models.Assignment) []*models.Assignment {
+	sort.Slice(assignments, func(i, j int) bool {
+		return assignments[i].DueDate.After(assignments[j].DueDate)
 	})
 
-	return assignments, nil
+	return assignments
 }
 
-func RemovePastAssignments(assignments []*models.Assignment) ([]*models.Assignment, error) {
+// RemovePastAssignments keeps only the assignments that are not due yet.
+func RemovePastAssignments(assignments []*models.Assignment) []*models.Assignment {
 	n := 0
-	var status DateStatus
-	var err error
-
 	for _, a := range assignments {
-		status, err = GetDateStatus(a.DueDate)
-		if err != nil {
-			fmt.Println("Error filtering")
-		}
-
 		// keep only if NOT past (today or future)
-		if status.Past {
+		if !GetDateStatus(a.DueDate).Past {
 			assignments[n] = a
 			n++
 		}
 	}
 
 	// Trim the slice (no reallocation)
-	return assignments[:n], nil
+	return assignments[:n]
 }
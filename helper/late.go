@@ -0,0 +1,77 @@
+package helper
+
+import (
+	"frontend/database/models"
+	"math"
+	"strconv"
+	"time"
+)
+
+// LateStatus describes a moment relative to an assignment's deadline.
+type LateStatus struct {
+	Late     bool // after the due date and the grace period
+	DaysLate int  // started days past the deadline, 0 when on time
+	Penalty  int  // percentage taken from the grade, 0..100
+}
+
+// Deadline returns the last moment a submission counts as on time: the due
+// date plus the grace period of the assignment's late policy.
+func Deadline(a *models.Assignment) time.Time {
+	grace := time.Duration(a.LatePolicy.GraceHours) * time.Hour
+	return a.DueDate.Add(grace)
+}
+
+// GetLateStatus computes how late a submission made at t is. Assignments
+// without a due date are never late.
+func GetLateStatus(a *models.Assignment, t time.Time) LateStatus {
+	deadline := Deadline(a)
+	if a.DueDate.IsZero() || !t.After(deadline) {
+		return LateStatus{}
+	}
+
+	days := int(math.Ceil(t.Sub(deadline).Hours() / 24))
+	status := LateStatus{Late: true, DaysLate: days}
+	if a.LatePolicy.Mode == models.LatePolicyPenalty {
+		status.Penalty = min(100, days*a.LatePolicy.PenaltyPerDay)
+	}
+
+	return status
+}
+
+// AcceptsSubmission reports whether the late policy lets a student turn in at t.
+func AcceptsSubmission(a *models.Assignment, t time.Time) bool {
+	switch a.LatePolicy.Mode {
+	case models.LatePolicyFlag, models.LatePolicyPenalty:
+		return true
+	default:
+		return !GetLateStatus(a, t).Late
+	}
+}
+
+// LatePenalty returns the percentage the policy takes for a stored submission.
+func LatePenalty(a *models.Assignment, s *models.Submission) int {
+	if a == nil || s == nil || !s.Late || a.LatePolicy.Mode != models.LatePolicyPenalty {
+		return 0
+	}
+	return min(100, s.DaysLate*a.LatePolicy.PenaltyPerDay)
+}
+
+// FinalGrade applies the late penalty to the raw grade of a submission.
+// Ungraded submissions or non numeric grades are returned as they are.
+func FinalGrade(a *models.Assignment, s *models.Submission) string {
+	if s == nil {
+		return ""
+	}
+
+	penalty := LatePenalty(a, s)
+	if penalty == 0 {
+		return s.Grade
+	}
+
+	g, err := strconv.Atoi(s.Grade)
+	if err != nil {
+		return s.Grade
+	}
+
+	return strconv.Itoa(g * (100 - penalty) / 100)
+}
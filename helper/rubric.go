@@ -0,0 +1,92 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"frontend/database/models"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseRubric reads the rubric JSON posted by the assignment editor. Empty
+// criteria and levels are dropped; a rubric without criteria becomes nil.
+func ParseRubric(raw string) (*models.Rubric, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return nil, nil
+	}
+
+	var in models.Rubric
+	if err := json.Unmarshal([]byte(raw), &in); err != nil {
+		return nil, fmt.Errorf("invalid rubric: %w", err)
+	}
+
+	out := &models.Rubric{}
+	for _, c := range in.Criteria {
+		c.Name = strings.TrimSpace(c.Name)
+		if c.Name == "" {
+			continue
+		}
+
+		levels := []models.RubricLevel{}
+		for _, l := range c.Levels {
+			l.Name = strings.TrimSpace(l.Name)
+			if l.Name == "" {
+				continue
+			}
+			if l.Points < 0 {
+				return nil, fmt.Errorf("criterion %q has negative points", c.Name)
+			}
+			levels = append(levels, l)
+		}
+		if len(levels) == 0 {
+			return nil, fmt.Errorf("criterion %q has no levels", c.Name)
+		}
+
+		c.Levels = levels
+		out.Criteria = append(out.Criteria, c)
+	}
+
+	if len(out.Criteria) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// RubricMax is the best possible score: the highest level of every criterion.
+func RubricMax(r *models.Rubric) int {
+	total := 0
+	for _, c := range r.Criteria {
+		best := 0
+		for _, l := range c.Levels {
+			best = max(best, l.Points)
+		}
+		total += best
+	}
+	return total
+}
+
+// ScoreRubric adds up the points of the chosen level of every criterion.
+func ScoreRubric(r *models.Rubric, selected []int) (int, error) {
+	if len(selected) != len(r.Criteria) {
+		return 0, fmt.Errorf("expected %d scores, got %d", len(r.Criteria), len(selected))
+	}
+
+	points := 0
+	for i, c := range r.Criteria {
+		if selected[i] < 0 || selected[i] >= len(c.Levels) {
+			return 0, fmt.Errorf("criterion %q has no level %d", c.Name, selected[i])
+		}
+		points += c.Levels[selected[i]].Points
+	}
+	return points, nil
+}
+
+// RubricGrade turns rubric points into the 0-100 grade stored on submissions.
+func RubricGrade(points, maxPoints int) string {
+	if maxPoints <= 0 {
+		return "0"
+	}
+	return strconv.Itoa(int(math.Round(float64(points) * 100 / float64(maxPoints))))
+}
@@ -0,0 +1,108 @@
+// Package api serves the JSON API under /api/v1. Authentication and class
+// membership are checked by the router before these handlers run.
+package api
+
+import (
+	"encoding/json"
+	"frontend/database/models"
+	"frontend/internal/authz"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"net/http"
+)
+
+// maxBodyBytes caps the size of a JSON request body
+const maxBodyBytes = 1 << 20
+
+// Error codes of the error body
+const (
+	CodeBadRequest   = "bad_request"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeInternal     = "internal"
+)
+
+// ErrorBody is the body of every error response: {"error": {...}}
+type ErrorBody struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// WriteJSON writes v as the JSON body of the response
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("failed to write JSON response", telemetry.Err(err))
+	}
+}
+
+// WriteError writes an error body with the code that matches status
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteJSON(w, status, ErrorBody{Error: ErrorDetail{Code: codeFor(status), Message: message}})
+}
+
+func codeFor(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	default:
+		return CodeInternal
+	}
+}
+
+// decode reads a JSON body into v, answering 400 itself when it can't
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// User is a user as the API shows it, without credentials
+type User struct {
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Role      string `json:"role"`
+}
+
+func userView(u *models.User) User {
+	return User{Username: u.Username, FirstName: u.FirstName, LastName: u.LastName, Role: u.Role}
+}
+
+// Class is a class together with the role of the caller in it
+type Class struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Subject     string `json:"subject"`
+	Role        string `json:"role"`
+}
+
+func classView(user *models.User, c *models.Class) Class {
+	return Class{
+		Id:          c.Id,
+		Name:        c.Name,
+		Description: c.Description,
+		Subject:     c.Subject,
+		Role:        authz.ClassRole(user, c),
+	}
+}
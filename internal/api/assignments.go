@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"frontend/database"
+	"frontend/database/models"
+	"frontend/helper"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadAssignment answers 404 itself when the assignment isn't in the class
+func loadAssignment(store *database.Store, w http.ResponseWriter, classId, assignmentId int) (*models.Assignment, bool) {
+	assignment, err := database.GetWithPrefix[models.Assignment](store, database.Buckets["assignments"], strconv.Itoa(assignmentId), strconv.Itoa(classId))
+	if err != nil {
+		WriteError(w, http.StatusNotFound, "Assignment not found")
+		return nil, false
+	}
+	return assignment, true
+}
+
+// ListAssignments returns the assignments of a class, latest due date first
+func ListAssignments(store *database.Store, w http.ResponseWriter, r *http.Request, classId int) {
+	WriteJSON(w, http.StatusOK, helper.OrderAssignments(database.ListAssignmentsOfClass(store, classId)))
+}
+
+// GetAssignment returns one assignment of a class
+func GetAssignment(store *database.Store, w http.ResponseWriter, r *http.Request, classId, assignmentId int) {
+	if assignment, ok := loadAssignment(store, w, classId, assignmentId); ok {
+		WriteJSON(w, http.StatusOK, assignment)
+	}
+}
+
+type NewAssignment struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	DueDate     string `json:"due_date"` // RFC 3339, or wall time of the class's school
+}
+
+// CreateAssignment adds an assignment to a class
+func CreateAssignment(store *database.Store, w http.ResponseWriter, r *http.Request, classId int) {
+	var body NewAssignment
+	if !decode(w, r, &body) {
+		return
+	}
+
+	title := strings.TrimSpace(body.Title)
+	if title == "" {
+		WriteError(w, http.StatusBadRequest, "title is required")
+		return
+	}
+
+	dueDate, err := time.Parse(time.RFC3339, body.DueDate)
+	if err != nil {
+		dueDate, err = helper.ParseDueDate(body.DueDate, database.ClassLocation(store, classId))
+	}
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid due_date %q", body.DueDate))
+		return
+	}
+
+	assignment, err := database.CreateAssignment(store, classId, title, body.Description, dueDate)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "Failed to create assignment")
+		return
+	}
+	WriteJSON(w, http.StatusCreated, assignment)
+}
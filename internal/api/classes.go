@@ -0,0 +1,106 @@
+package api
+
+import (
+	"frontend/database"
+	"frontend/database/models"
+	"frontend/internal/gradebook"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Me returns the caller
+func Me(w http.ResponseWriter, r *http.Request, user *models.User) {
+	WriteJSON(w, http.StatusOK, userView(user))
+}
+
+// ListClasses returns the classes the caller belongs to
+func ListClasses(store *database.Store, w http.ResponseWriter, r *http.Request, user *models.User) {
+	classes, err := database.ListClassesForUser(store, user.Username)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list classes", telemetry.Err(err))
+		WriteError(w, http.StatusInternalServerError, "Failed to list classes")
+		return
+	}
+
+	list := make([]Class, 0, len(classes))
+	for _, c := range classes {
+		list = append(list, classView(user, c))
+	}
+	WriteJSON(w, http.StatusOK, list)
+}
+
+// GetClass returns one class of the caller
+func GetClass(w http.ResponseWriter, r *http.Request, user *models.User, class *models.Class) {
+	WriteJSON(w, http.StatusOK, classView(user, class))
+}
+
+type GradeAssignment struct {
+	Id      int       `json:"id"`
+	Title   string    `json:"title"`
+	DueDate time.Time `json:"due_date"`
+}
+
+type Grade struct {
+	AssignmentId int    `json:"assignment_id"`
+	Grade        string `json:"grade"` // with the late penalty applied, "" if ungraded
+	Submitted    bool   `json:"submitted"`
+	Late         bool   `json:"late"`
+	Missing      bool   `json:"missing"`
+}
+
+type StudentGrades struct {
+	User
+	Grades  []Grade  `json:"grades"`
+	Average *float64 `json:"average"` // null until something is graded
+	Missing int      `json:"missing"`
+}
+
+type Grades struct {
+	Assignments []GradeAssignment `json:"assignments"` // oldest due date first
+	Students    []StudentGrades   `json:"students"`
+}
+
+// ListGrades returns the gradebook of the class, or only the caller's row
+// unless all is set.
+func ListGrades(store *database.Store, w http.ResponseWriter, r *http.Request, user *models.User, class *models.Class, all bool) {
+	g, err := gradebook.Build(store, class.Id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to build gradebook", telemetry.Err(err))
+		WriteError(w, http.StatusInternalServerError, "Failed to build gradebook")
+		return
+	}
+
+	grades := Grades{Assignments: []GradeAssignment{}, Students: []StudentGrades{}}
+	for _, a := range g.Assignments {
+		grades.Assignments = append(grades.Assignments, GradeAssignment{Id: a.Id, Title: a.Title, DueDate: a.DueDate})
+	}
+	for _, row := range g.Rows {
+		if !all && row.Username != user.Username {
+			continue
+		}
+
+		student := StudentGrades{
+			User:    User{Username: row.Username, FirstName: row.FirstName, LastName: row.LastName, Role: models.RoleStudent},
+			Grades:  make([]Grade, 0, len(row.Cells)),
+			Missing: row.Missing,
+		}
+		for j, c := range row.Cells {
+			student.Grades = append(student.Grades, Grade{
+				AssignmentId: g.Assignments[j].Id,
+				Grade:        c.Grade,
+				Submitted:    c.Submitted,
+				Late:         c.Late,
+				Missing:      c.Missing,
+			})
+		}
+		if row.Graded > 0 {
+			average := row.Average
+			student.Average = &average
+		}
+		grades.Students = append(grades.Students, student)
+	}
+
+	WriteJSON(w, http.StatusOK, grades)
+}
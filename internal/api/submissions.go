@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"frontend/database"
+	"frontend/database/models"
+	"frontend/helper"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ListSubmissions returns every submission of an assignment
+func ListSubmissions(store *database.Store, w http.ResponseWriter, r *http.Request, classId, assignmentId int) {
+	if _, ok := loadAssignment(store, w, classId, assignmentId); !ok {
+		return
+	}
+
+	submissions, err := database.GetSubmissionsByAssignment(store, classId, assignmentId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list submissions", telemetry.Err(err))
+		WriteError(w, http.StatusInternalServerError, "Failed to list submissions")
+		return
+	}
+	WriteJSON(w, http.StatusOK, submissions)
+}
+
+// GetSubmission returns the submission of one student
+func GetSubmission(store *database.Store, w http.ResponseWriter, r *http.Request, classId, assignmentId int, student string) {
+	if _, ok := loadAssignment(store, w, classId, assignmentId); !ok {
+		return
+	}
+
+	submission, err := database.GetSubmission(store, classId, assignmentId, student)
+	if err != nil {
+		WriteError(w, http.StatusNotFound, "Submission not found")
+		return
+	}
+	WriteJSON(w, http.StatusOK, submission)
+}
+
+type SubmissionUpdate struct {
+	Description string `json:"description"`
+}
+
+// Submit turns in the caller's work. Only the text goes through the API;
+// files already attached to the submission are kept.
+func Submit(store *database.Store, w http.ResponseWriter, r *http.Request, classId, assignmentId int, username string) {
+	var body SubmissionUpdate
+	if !decode(w, r, &body) {
+		return
+	}
+
+	assignment, ok := loadAssignment(store, w, classId, assignmentId)
+	if !ok {
+		return
+	}
+
+	// Same late policy as the web form, stamped in the school's time zone
+	now := time.Now().In(database.ClassLocation(store, classId))
+	if !helper.AcceptsSubmission(assignment, now) {
+		WriteError(w, http.StatusForbidden, "The due date has passed")
+		return
+	}
+	lateStatus := helper.GetLateStatus(assignment, now)
+
+	submission, err := database.UpdateSubmission(store, classId, assignmentId, username, func(s *models.Submission) error {
+		s.Description = body.Description
+		s.SubmittedAt = now.Format(time.RFC3339)
+		s.Late = lateStatus.Late
+		s.DaysLate = lateStatus.DaysLate
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to save submission", telemetry.Err(err))
+		WriteError(w, http.StatusInternalServerError, "Failed to save submission")
+		return
+	}
+	telemetry.CountSubmission(submission.Late)
+	WriteJSON(w, http.StatusOK, submission)
+}
+
+type GradeUpdate struct {
+	Grade        string `json:"grade"` // ignored when the assignment has a rubric
+	Feedback     string `json:"feedback"`
+	RubricScores []int  `json:"rubric_scores"`
+}
+
+// GradeSubmission grades a student's submission. Feedback files are kept.
+func GradeSubmission(store *database.Store, w http.ResponseWriter, r *http.Request, classId, assignmentId int, student, grader string) {
+	var body GradeUpdate
+	if !decode(w, r, &body) {
+		return
+	}
+
+	assignment, ok := loadAssignment(store, w, classId, assignmentId)
+	if !ok {
+		return
+	}
+
+	grade := body.Grade
+	var rubricScores []int
+	if assignment.Rubric != nil {
+		points, err := helper.ScoreRubric(assignment.Rubric, body.RubricScores)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid rubric_scores: "+err.Error())
+			return
+		}
+		rubricScores = body.RubricScores
+		grade = helper.RubricGrade(points, helper.RubricMax(assignment.Rubric))
+	}
+
+	submission, err := database.GradeSubmission(store, classId, assignmentId, student, database.Grading{
+		Grade:        grade,
+		Feedback:     strings.TrimSpace(body.Feedback),
+		RubricScores: rubricScores,
+		GradedBy:     grader,
+		GradedAt:     time.Now().In(database.ClassLocation(store, classId)).Format(time.RFC3339),
+	})
+	if errors.Is(err, database.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "Submission not found")
+		return
+	}
+	if errors.Is(err, database.ErrInvalidGrade) {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("Invalid grade: want a whole number from 0 to %d", database.MaxGrade))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to grade submission", telemetry.Err(err))
+		WriteError(w, http.StatusInternalServerError, "Failed to grade submission")
+		return
+	}
+	WriteJSON(w, http.StatusOK, submission)
+}
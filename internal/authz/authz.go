@@ -0,0 +1,88 @@
+// Package authz decides what a user may do. Global actions depend on the
+// user's role; class actions on the role the user has in that class, so the
+// same person can teach one class and attend another.
+package authz
+
+import (
+	"frontend/database/models"
+	"slices"
+)
+
+type Action string
+
+const (
+	Administer Action = "administer" // admin console, not tied to a class
+
+	ViewClass         Action = "view_class"
+	ManageAssignments Action = "manage_assignments" // create, edit and delete assignments
+	SubmitWork        Action = "submit_work"
+	ViewOwnSubmission Action = "view_own_submission"
+	ViewSubmissions   Action = "view_submissions" // everyone's submissions
+	GradeSubmissions  Action = "grade_submissions"
+	ViewGradebook     Action = "view_gradebook"
+)
+
+// classPolicy lists what each class role allows; anything else is denied.
+var classPolicy = map[string][]Action{
+	models.RoleProfessor: {ViewClass, ManageAssignments, ViewSubmissions, GradeSubmissions, ViewGradebook},
+	models.RoleStudent:   {ViewClass, SubmitWork, ViewOwnSubmission},
+}
+
+// ClassRole is the role of user inside class, "" when not a member. Without
+// an entry in Class.Roles professors teach and everyone else attends.
+func ClassRole(user *models.User, class *models.Class) string {
+	if user == nil || class == nil || !slices.Contains(class.Users, user.Username) {
+		return ""
+	}
+	if role, ok := class.Roles[user.Username]; ok {
+		return role
+	}
+	if user.Role == models.RoleProfessor {
+		return models.RoleProfessor
+	}
+	return models.RoleStudent
+}
+
+// Can reports whether user may perform action. class is nil for global actions.
+func Can(user *models.User, class *models.Class, action Action) bool {
+	if user == nil || user.Disabled {
+		return false
+	}
+	if action == Administer {
+		return user.Role == models.RoleAdmin
+	}
+	return slices.Contains(classPolicy[ClassRole(user, class)], action)
+}
+
+// Scopes of personal API tokens. A token can never do more than its owner;
+// its scopes only narrow which class actions it may be used for.
+const (
+	ScopeReadGrades  = "grades:read"
+	ScopeSubmitWork  = "work:submit"
+	ScopeManageClass = "class:manage"
+)
+
+// Scopes lists every scope in the order they are offered.
+var Scopes = []string{ScopeReadGrades, ScopeSubmitWork, ScopeManageClass}
+
+var scopePolicy = map[string][]Action{
+	ScopeReadGrades:  {ViewClass, ViewOwnSubmission, ViewSubmissions, ViewGradebook},
+	ScopeSubmitWork:  {ViewClass, SubmitWork, ViewOwnSubmission},
+	ScopeManageClass: {ViewClass, ManageAssignments, ViewSubmissions, GradeSubmissions, ViewGradebook},
+}
+
+// ValidScope reports whether scope is one of Scopes.
+func ValidScope(scope string) bool {
+	_, ok := scopePolicy[scope]
+	return ok
+}
+
+// ScopeAllows reports whether any of scopes covers action.
+func ScopeAllows(scopes []string, action Action) bool {
+	for _, scope := range scopes {
+		if slices.Contains(scopePolicy[scope], action) {
+			return true
+		}
+	}
+	return false
+}
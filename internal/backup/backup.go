@@ -0,0 +1,270 @@
+// Package backup takes hot snapshots of the database on a schedule, keeps a
+// number of daily and weekly copies, optionally uploads them to the object
+// storage and restores a snapshot over the database file.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"frontend/database"
+	"frontend/internal/telemetry"
+	"frontend/storage"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// nameLayout names snapshot files after the UTC time they were taken
+const nameLayout = "school-20060102-150405.db"
+
+type Options struct {
+	Dir        string        // where snapshots are written
+	Every      time.Duration // time between snapshots, 0 turns them off
+	KeepDaily  int           // newest snapshot of each of the last KeepDaily days
+	KeepWeekly int           // newest snapshot of each of the last KeepWeekly weeks
+	// Upload also sends each snapshot to the object storage under
+	// "backups/". Only use it with a private bucket.
+	Upload storage.Storage
+}
+
+// Snapshot is a backup file in Options.Dir
+type Snapshot struct {
+	Name string
+	Path string
+	Size int64
+	Time time.Time
+}
+
+// FileName is the name of a snapshot taken at t
+func FileName(t time.Time) string {
+	return t.UTC().Format(nameLayout)
+}
+
+// Start takes a snapshot every opts.Every until ctx is done. The first one
+// is due opts.Every after the newest snapshot already in the directory.
+func Start(ctx context.Context, store *database.Store, opts Options) {
+	if opts.Every <= 0 {
+		return
+	}
+	go func() {
+		wait := time.Duration(0)
+		if list, err := List(opts.Dir); err == nil && len(list) > 0 {
+			wait = max(0, time.Until(list[0].Time.Add(opts.Every)))
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-timer.C:
+				if _, err := Take(ctx, store, opts, now); err != nil {
+					slog.Error("backup failed", telemetry.Err(err))
+				}
+				timer.Reset(opts.Every)
+			}
+		}
+	}()
+}
+
+// Take writes a snapshot to opts.Dir, uploads it when opts.Upload is set and
+// prunes the snapshots the retention doesn't keep.
+func Take(ctx context.Context, store *database.Store, opts Options, now time.Time) (*Snapshot, error) {
+	if err := os.MkdirAll(opts.Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	name := FileName(now)
+	path := filepath.Join(opts.Dir, name)
+	size, err := Write(ctx, store, path)
+	if err != nil {
+		return nil, fmt.Errorf("write snapshot: %w", err)
+	}
+	snapshot := &Snapshot{Name: name, Path: path, Size: size, Time: now.UTC().Truncate(time.Second)}
+	slog.Info("backup taken", slog.String("file", path), slog.Int64("bytes", size))
+
+	if opts.Upload != nil {
+		if err := upload(ctx, opts.Upload, snapshot); err != nil {
+			// The local copy is still good, keep going with the retention
+			slog.Error("backup upload failed", slog.String("file", name), telemetry.Err(err))
+		}
+	}
+
+	removed, err := Prune(opts.Dir, opts.KeepDaily, opts.KeepWeekly)
+	if err != nil {
+		return snapshot, fmt.Errorf("prune snapshots: %w", err)
+	}
+	for _, old := range removed {
+		slog.Info("backup pruned", slog.String("file", old.Name))
+	}
+	return snapshot, nil
+}
+
+// Write saves a snapshot of the database to path
+func Write(ctx context.Context, store *database.Store, path string) (int64, error) {
+	return writeFile(path, func(w io.Writer) (int64, error) {
+		return database.WriteBackup(store.WithContext(ctx), w)
+	})
+}
+
+func upload(ctx context.Context, files storage.Storage, snapshot *Snapshot) error {
+	f, err := os.Open(snapshot.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = files.UploadFile(ctx, "backups/"+snapshot.Name, f)
+	return err
+}
+
+// writeFile fills path through a temporary file in the same directory, so
+// a crash never leaves a half written snapshot under the final name
+func writeFile(path string, fill func(w io.Writer) (int64, error)) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := fill(tmp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, os.Rename(tmp.Name(), path)
+}
+
+// List returns the snapshots in dir, newest first. Other files are ignored.
+func List(dir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Snapshot
+	for _, e := range entries {
+		t, err := time.Parse(nameLayout, e.Name())
+		if err != nil || !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, Snapshot{Name: e.Name(), Path: filepath.Join(dir, e.Name()), Size: info.Size(), Time: t})
+	}
+	slices.SortFunc(list, func(a, b Snapshot) int { return b.Time.Compare(a.Time) })
+	return list, nil
+}
+
+// Find returns the snapshot called name in dir. Only names List would show
+// are accepted, so name can come from a URL.
+func Find(dir, name string) (*Snapshot, error) {
+	list, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range list {
+		if s.Name == name {
+			return &s, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+// Prune deletes the snapshots that are neither the newest of one of the
+// last keepDaily days nor of one of the last keepWeekly ISO weeks, both
+// counted in UTC. The newest snapshot is always kept.
+func Prune(dir string, keepDaily, keepWeekly int) ([]Snapshot, error) {
+	list, err := List(dir)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+
+	keep := map[string]bool{list[0].Name: true}
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+	for _, s := range list {
+		day := s.Time.Format("2006-01-02")
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep[s.Name] = true
+		}
+		year, week := s.Time.ISOWeek()
+		w := fmt.Sprintf("%d-%02d", year, week)
+		if !weeks[w] && len(weeks) < keepWeekly {
+			weeks[w] = true
+			keep[s.Name] = true
+		}
+	}
+
+	var removed []Snapshot
+	for _, s := range list {
+		if keep[s.Name] {
+			continue
+		}
+		if err := os.Remove(s.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, s)
+	}
+	return removed, nil
+}
+
+// Restore replaces the database at dbPath with the snapshot at from, after
+// checking the snapshot is sound. The server must be stopped: a database
+// still open by another process is refused. The replaced file is kept next
+// to it and its path returned.
+func Restore(from, dbPath string) (string, error) {
+	if err := database.ValidateBackup(from); err != nil {
+		return "", fmt.Errorf("invalid snapshot: %w", err)
+	}
+
+	if _, err := os.Stat(dbPath); err == nil {
+		// bbolt holds an exclusive lock while the server runs
+		db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{Timeout: time.Second})
+		if err != nil {
+			return "", fmt.Errorf("database is in use, stop the server first: %w", err)
+		}
+		db.Close()
+	}
+
+	src, err := os.Open(from)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmp := dbPath + ".restore"
+	if _, err := writeFile(tmp, func(w io.Writer) (int64, error) { return io.Copy(w, src) }); err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp)
+
+	var previous string
+	if _, err := os.Stat(dbPath); err == nil {
+		previous = strings.TrimSuffix(dbPath, ".db") + ".pre-restore-" + time.Now().UTC().Format("20060102-150405") + ".db"
+		if err := os.Rename(dbPath, previous); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return previous, err
+	}
+	return previous, nil
+}
@@ -0,0 +1,460 @@
+// Package cli holds the operator commands of the server binary, run as
+// "server [flags] <command> [args]". They work on the database file of the
+// configuration, which the running server keeps locked: stop it first.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"frontend/auth"
+	"frontend/database"
+	"frontend/database/models"
+	"frontend/internal/authz"
+	"frontend/internal/backup"
+	"frontend/internal/config"
+	"frontend/internal/gradebook"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// env is what every command gets to work with
+type env struct {
+	ctx    context.Context
+	cfg    *config.Config
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(e *env, fs *flag.FlagSet, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"create-user", "[-role r] [-password-stdin] <username> <first name> <last name>", "add a user, with a random password unless one is given", createUser},
+		{"reset-password", "[-password-stdin] <username>", "set a new password, random unless given, and end the user's sessions", resetPassword},
+		{"enroll", "[-role r] <class id> <username>", "add a user to a class, optionally with a class role", enroll},
+		{"list-classes", "", "list every class with its subject and members", listClasses},
+		{"export-grades", "[-format csv|xlsx] [-o file] <class id>", "write the gradebook of a class", exportGrades},
+		{"backup", "[-o file]", "write a snapshot of the database, to the backup directory by default", backupDB},
+		{"restore", "<snapshot>", "check a snapshot and put it in place of the database", restore},
+		{"migrate", "[-dry-run]", "apply pending schema migrations, or report them", migrate},
+		{"compact", "", "rewrite the database file without its free pages", compact},
+		{"reindex", "", "rebuild the class membership and subject indexes", reindex},
+		{"help", "", "show this list", help},
+	}
+}
+
+// ErrUnknownCommand is returned by Run for a command that doesn't exist
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Run runs the command named by args[0]
+func Run(ctx context.Context, cfg *config.Config, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	e := &env{ctx: ctx, cfg: cfg, stdin: stdin, stdout: stdout, stderr: stderr}
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		fs.Usage = func() {
+			fmt.Fprintf(stderr, "usage: server %s %s\n%s\n", c.name, c.args, c.summary)
+			fs.PrintDefaults()
+		}
+		err := c.run(e, fs, args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	help(e, nil, nil)
+	return fmt.Errorf("%w %q", ErrUnknownCommand, args[0])
+}
+
+func help(e *env, _ *flag.FlagSet, _ []string) error {
+	fmt.Fprintln(e.stderr, "commands:")
+	w := tabwriter.NewWriter(e.stderr, 0, 4, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", c.name, c.summary)
+	}
+	return w.Flush()
+}
+
+// parse reads the flags of a command and checks it got want arguments
+func parse(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != want {
+		fs.Usage()
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", fs.Name(), want, fs.NArg())
+	}
+	return fs.Args(), nil
+}
+
+// open opens the database like the server would, migrating it first. A
+// missing file is created without sample data, so the first admin can be
+// made with create-user.
+func (e *env) open() (*database.Store, error) {
+	store, err := database.Init(e.cfg.DBPath, database.InitOptions{TimeZone: e.cfg.TimeZone})
+	if err != nil {
+		return nil, err
+	}
+	return store.WithContext(e.ctx), nil
+}
+
+// openExisting opens the database without migrating it, for the commands
+// that work on the file as it is
+func (e *env) openExisting() (*database.Store, error) {
+	if _, err := os.Stat(e.cfg.DBPath); err != nil {
+		return nil, err
+	}
+	store, _, err := database.Open(e.cfg.DBPath)
+	if err != nil {
+		return nil, err
+	}
+	return store.WithContext(e.ctx), nil
+}
+
+// passwordOrRandom reads the password from the first line of standard input
+// when fromStdin is set, so it stays out of the process list and the shell
+// history. Otherwise it makes a random one and prints it for the operator.
+func (e *env) passwordOrRandom(fromStdin bool, username string) (string, error) {
+	if fromStdin {
+		line, err := bufio.NewReader(e.stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		password := strings.TrimRight(line, "\r\n")
+		if len(password) < auth.MinPasswordLength {
+			return "", fmt.Errorf("the password needs at least %d characters", auth.MinPasswordLength)
+		}
+		return password, nil
+	}
+	password, err := auth.RandomPassword(10)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(e.stdout, "password for %s: %s\n", username, password)
+	return password, nil
+}
+
+func createUser(e *env, fs *flag.FlagSet, args []string) error {
+	role := fs.String("role", models.RoleStudent, "student, professor or admin")
+	fromStdin := fs.Bool("password-stdin", false, "read the password from standard input instead of making a random one")
+	args, err := parse(fs, args, 3)
+	if err != nil {
+		return err
+	}
+	username := args[0]
+	if !database.ValidName.MatchString(username) {
+		return fmt.Errorf("invalid username %q: use letters, digits, dot, dash or underscore", username)
+	}
+	if !slices.Contains(database.Roles, *role) {
+		return fmt.Errorf("invalid role %q", *role)
+	}
+
+	store, err := e.open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	exists, err := database.Exists(store, database.Buckets["users"], username)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("user %s already exists", username)
+	}
+
+	pw, err := e.passwordOrRandom(*fromStdin, username)
+	if err != nil {
+		return err
+	}
+	if err := database.CreateUser(store, username, pw, args[1], args[2], *role); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "created %s %s (%s) as %s\n", args[1], args[2], username, *role)
+	return nil
+}
+
+func resetPassword(e *env, fs *flag.FlagSet, args []string) error {
+	fromStdin := fs.Bool("password-stdin", false, "read the password from standard input instead of making a random one")
+	args, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	username := args[0]
+
+	store, err := e.open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if _, err := database.Get[models.User](store, database.Buckets["users"], username); err != nil {
+		return fmt.Errorf("user %s not found", username)
+	}
+	pw, err := e.passwordOrRandom(*fromStdin, username)
+	if err != nil {
+		return err
+	}
+	if err := database.SetPassword(store, username, pw); err != nil {
+		return err
+	}
+	removed, err := database.RevokeUserSessions(store, username, "")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "password of %s changed, %d sessions closed\n", username, removed)
+	return nil
+}
+
+func enroll(e *env, fs *flag.FlagSet, args []string) error {
+	role := fs.String("role", "", "class role: student or professor, the user's own role when empty")
+	args, err := parse(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	classId, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid class id %q", args[0])
+	}
+	username := args[1]
+	if *role != "" && !slices.Contains(database.ClassRoles, *role) {
+		return fmt.Errorf("invalid class role %q", *role)
+	}
+
+	store, err := e.open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if _, err := database.Get[models.User](store, database.Buckets["users"], username); err != nil {
+		return fmt.Errorf("user %s not found", username)
+	}
+	class, err := database.GetClass(store, classId)
+	if err != nil {
+		return fmt.Errorf("class %d not found", classId)
+	}
+	if err := database.AddUserToClass(store, classId, username); err != nil {
+		return err
+	}
+	if *role != "" {
+		if err := database.SetClassRole(store, classId, username, *role); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(e.stdout, "%s enrolled in %d %s\n", username, class.Id, class.Name)
+	return nil
+}
+
+func listClasses(e *env, fs *flag.FlagSet, args []string) error {
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	store, err := e.open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	classes, err := database.ListClasses(store)
+	if err != nil {
+		return err
+	}
+	users, err := database.ListUsers(store)
+	if err != nil {
+		return err
+	}
+	byName := map[string]*models.User{}
+	for _, u := range users {
+		byName[u.Username] = u
+	}
+
+	w := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSUBJECT\tPROFESSORS\tSTUDENTS")
+	for _, c := range classes {
+		var professors []string
+		students := 0
+		for _, username := range c.Users {
+			if authz.ClassRole(byName[username], c) == models.RoleProfessor {
+				professors = append(professors, username)
+			} else {
+				students++
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\n", c.Id, c.Name, c.Subject, strings.Join(professors, ", "), students)
+	}
+	return w.Flush()
+}
+
+func exportGrades(e *env, fs *flag.FlagSet, args []string) error {
+	format := fs.String("format", "csv", "csv or xlsx")
+	out := fs.String("o", "", "file to write, standard output when empty")
+	args, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	classId, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid class id %q", args[0])
+	}
+	if *format != "csv" && *format != "xlsx" {
+		return fmt.Errorf("invalid format %q", *format)
+	}
+
+	store, err := e.open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	book, err := gradebook.Build(store, classId)
+	if err != nil {
+		return err
+	}
+
+	w := e.stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "xlsx" {
+		return book.WriteXLSX(w)
+	}
+	return book.WriteCSV(w)
+}
+
+func backupDB(e *env, fs *flag.FlagSet, args []string) error {
+	out := fs.String("o", "", "file to write, a new snapshot in the backup directory when empty")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	store, err := e.openExisting()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	path := *out
+	if path == "" {
+		if err := os.MkdirAll(e.cfg.Backup.Dir, 0700); err != nil {
+			return err
+		}
+		path = filepath.Join(e.cfg.Backup.Dir, backup.FileName(time.Now()))
+	}
+	n, err := backup.Write(e.ctx, store, path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "wrote %s (%d bytes)\n", path, n)
+	return nil
+}
+
+func restore(e *env, fs *flag.FlagSet, args []string) error {
+	args, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	previous, err := backup.Restore(args[0], e.cfg.DBPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "restored %s from %s\n", e.cfg.DBPath, args[0])
+	if previous != "" {
+		fmt.Fprintf(e.stdout, "the replaced database is at %s\n", previous)
+	}
+	return nil
+}
+
+func migrate(e *env, fs *flag.FlagSet, args []string) error {
+	dryRun := fs.Bool("dry-run", false, "report what would change without applying it")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	store, err := e.openExisting()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	version, err := database.GetSchemaVersion(store)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "schema version %d, binary at %d\n", version, database.SchemaVersion())
+
+	results, err := database.Migrate(store, *dryRun)
+	for _, r := range results {
+		verb := "changed"
+		if *dryRun {
+			verb = "would change"
+		}
+		fmt.Fprintf(e.stdout, "  %d %s: %d records %s\n", r.Version, r.Name, r.Changed, verb)
+	}
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(e.stdout, "up to date")
+	}
+	return nil
+}
+
+func compact(e *env, fs *flag.FlagSet, args []string) error {
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	before, after, err := database.Compact(e.cfg.DBPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "compacted %s from %d to %d bytes, the old file is at %s.pre-compact\n", e.cfg.DBPath, before, after, e.cfg.DBPath)
+	return nil
+}
+
+func reindex(e *env, fs *flag.FlagSet, args []string) error {
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	store, err := e.openExisting()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	n, err := database.RebuildIndexes(store)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "indexed %d classes\n", n)
+	return nil
+}
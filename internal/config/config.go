@@ -0,0 +1,360 @@
+// Package config loads the server settings. Each setting has a default, can
+// be written in a KEY=value config file, overridden by the environment
+// variable of the same name, and for the non secret ones by a command line
+// flag. Everything is validated at startup so a bad value stops the server
+// with a message naming the setting instead of failing on first use; what
+// only serving needs is checked by ValidateServe.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Cookie security modes for SecureCookies
+const (
+	CookiesAuto   = "auto"   // Secure when the request came over HTTPS
+	CookiesAlways = "always" // always Secure, for deployments behind TLS
+	CookiesNever  = "never"  // never Secure, for plain HTTP development
+)
+
+type Config struct {
+	Addr      string // address the server listens on
+	DBPath    string // bbolt database file
+	StaticDir string // directory served under /static/
+
+	// TimeZone is the IANA zone of the default school when a new database
+	// creates it; existing schools keep the zone set in the admin console
+	TimeZone string
+	// Seed fills a new database with sample users, a class and an assignment
+	Seed bool
+
+	// BaseURL is the address users reach the site at, like
+	// https://aula.example.com, for the links it hands out. Empty means the
+	// listen address, fine for development only.
+	BaseURL string
+
+	MaxUploadBytes int64  // largest request body accepted, files included
+	SecureCookies  string // CookiesAuto, CookiesAlways or CookiesNever
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For and
+	// X-Forwarded-Proto headers are believed; from anyone else they are ignored
+	TrustedProxies []netip.Prefix
+
+	LogLevel       string // debug, info, warn or error
+	LogFormat      string // text or json
+	TracesExporter string // none, stdout or otlp
+	MetricsToken   string // bearer token Prometheus scrapes /metrics with
+
+	Storage Storage
+	Backup  Backup
+}
+
+// Backup schedules the database snapshots
+type Backup struct {
+	Dir        string        // where snapshots are kept
+	Every      time.Duration // time between snapshots, 0 for none
+	KeepDaily  int           // days with a snapshot kept
+	KeepWeekly int           // weeks with a snapshot kept
+	Upload     bool          // also send snapshots to the object storage
+}
+
+// Storage selects and configures the object store for uploaded files
+type Storage struct {
+	Backend string // b2 or local
+	Dir     string // root of the local backend
+
+	B2KeyID   string
+	B2AppKey  string
+	B2Bucket  string
+	B2BaseURL string
+}
+
+// ErrUsage is returned for bad command line flags, after the usage has been
+// printed
+var ErrUsage = errors.New("invalid flags")
+
+// setting is one configuration value. flag is empty for secrets, which
+// shouldn't end up in the process list.
+type setting struct {
+	env   string
+	flag  string
+	def   string
+	usage string
+	set   func(c *Config, v string) error
+}
+
+var settings = []setting{
+	{"LISTEN_ADDR", "addr", ":3000", "address to listen on", func(c *Config, v string) error {
+		if _, _, err := net.SplitHostPort(v); err != nil {
+			return err
+		}
+		c.Addr = v
+		return nil
+	}},
+	{"DB_PATH", "db", "data/school.db", "path of the database file", str(func(c *Config) *string { return &c.DBPath })},
+	{"STATIC_DIR", "static", "static", "directory of the static assets", str(func(c *Config) *string { return &c.StaticDir })},
+	{"TIME_ZONE", "tz", "America/La_Paz", "time zone of the default school of a new database", func(c *Config, v string) error {
+		if _, err := time.LoadLocation(v); err != nil {
+			return fmt.Errorf("unknown time zone %q", v)
+		}
+		c.TimeZone = v
+		return nil
+	}},
+	{"SEED_DATA", "seed", "false", "fill a new database with sample data, for development", boolean(func(c *Config) *bool { return &c.Seed })},
+	{"BASE_URL", "base-url", "", "address users reach the site at, like https://aula.example.com", func(c *Config, v string) error {
+		if v == "" {
+			return nil
+		}
+		u, err := url.Parse(v)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("want an http or https address like https://aula.example.com, got %q", v)
+		}
+		c.BaseURL = strings.TrimRight(v, "/")
+		return nil
+	}},
+	{"MAX_UPLOAD_MB", "max-upload-mb", "32", "largest accepted upload in MB", func(c *Config, v string) error {
+		mb, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || mb <= 0 || mb > 4096 {
+			return fmt.Errorf("want a size between 1 and 4096, got %q", v)
+		}
+		c.MaxUploadBytes = mb << 20
+		return nil
+	}},
+	{"SECURE_COOKIES", "secure-cookies", CookiesAuto, "mark cookies Secure: auto, always or never", oneOf(func(c *Config) *string { return &c.SecureCookies }, CookiesAuto, CookiesAlways, CookiesNever)},
+	{"TRUSTED_PROXIES", "trusted-proxies", "", "comma separated addresses or CIDR ranges of the reverse proxies in front", func(c *Config, v string) error {
+		for _, p := range strings.Split(v, ",") {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			prefix, err := netip.ParsePrefix(p)
+			if err != nil {
+				addr, aerr := netip.ParseAddr(p)
+				if aerr != nil {
+					return fmt.Errorf("want addresses or CIDR ranges, got %q", p)
+				}
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+			c.TrustedProxies = append(c.TrustedProxies, prefix.Masked())
+		}
+		return nil
+	}},
+	{"LOG_LEVEL", "log-level", "info", "debug, info, warn or error", oneOf(func(c *Config) *string { return &c.LogLevel }, "debug", "info", "warn", "error")},
+	{"LOG_FORMAT", "log-format", "text", "text or json", oneOf(func(c *Config) *string { return &c.LogFormat }, "text", "json")},
+	{"OTEL_TRACES_EXPORTER", "traces", "none", "none, stdout or otlp", oneOf(func(c *Config) *string { return &c.TracesExporter }, "none", "stdout", "otlp")},
+	{"METRICS_TOKEN", "", "", "", str(func(c *Config) *string { return &c.MetricsToken })},
+	{"STORAGE_BACKEND", "storage", "b2", "where uploads are kept: b2 or local", oneOf(func(c *Config) *string { return &c.Storage.Backend }, "b2", "local")},
+	{"STORAGE_DIR", "storage-dir", "data/files", "directory of the local storage backend", str(func(c *Config) *string { return &c.Storage.Dir })},
+	{"BACKUP_DIR", "backup-dir", "data/backups", "directory of the database snapshots", str(func(c *Config) *string { return &c.Backup.Dir })},
+	{"BACKUP_EVERY", "backup-every", "24h", "time between database snapshots, 0 to turn them off", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 || d > 0 && d < time.Minute {
+			return fmt.Errorf("want 0 or a duration of at least 1m, got %q", v)
+		}
+		c.Backup.Every = d
+		return nil
+	}},
+	{"BACKUP_KEEP_DAILY", "backup-keep-daily", "7", "days to keep a snapshot of", count(func(c *Config) *int { return &c.Backup.KeepDaily })},
+	{"BACKUP_KEEP_WEEKLY", "backup-keep-weekly", "4", "weeks to keep a snapshot of", count(func(c *Config) *int { return &c.Backup.KeepWeekly })},
+	{"BACKUP_UPLOAD", "backup-upload", "false", "also upload snapshots to the object storage, private B2 buckets only", boolean(func(c *Config) *bool { return &c.Backup.Upload })},
+	{"B2_KEY_ID", "", "", "", str(func(c *Config) *string { return &c.Storage.B2KeyID })},
+	{"B2_APP_KEY", "", "", "", str(func(c *Config) *string { return &c.Storage.B2AppKey })},
+	{"B2_BUCKET", "", "", "", str(func(c *Config) *string { return &c.Storage.B2Bucket })},
+	{"B2_BASE_URL", "", "", "", str(func(c *Config) *string { return &c.Storage.B2BaseURL })},
+}
+
+// flagValue is the command line value of a setting
+type flagValue struct {
+	value   string
+	boolean bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *flagValue) Set(v string) error {
+	f.value = v
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.boolean
+}
+
+func str(field func(c *Config) *string) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func boolean(field func(c *Config) *bool) func(c *Config, v string) error {
+	return func(c *Config, v string) (err error) {
+		*field(c), err = strconv.ParseBool(v)
+		return err
+	}
+}
+
+func count(field func(c *Config) *int) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("want a number from 0 up, got %q", v)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func oneOf(field func(c *Config) *string, allowed ...string) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		v = strings.ToLower(v)
+		for _, a := range allowed {
+			if v == a {
+				*field(c) = v
+				return nil
+			}
+		}
+		return fmt.Errorf("want one of %s, got %q", strings.Join(allowed, ", "), v)
+	}
+}
+
+// Load reads the configuration from the command line args, the environment
+// and the config file, in that order of precedence, and returns the
+// arguments left after the flags. The file is the one
+// given by -config or CONFIG_FILE, else .env, else the older .venv; it is
+// optional unless named explicitly. Its values also reach the process
+// environment, so OTEL_* and similar variables can live there too.
+func Load(args []string, stderr io.Writer) (*Config, []string, error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: server [flags] [command [args]]")
+		fmt.Fprintln(stderr, "Without a command it serves the site, \"server help\" lists the commands.")
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "KEY=value file to read settings from")
+	flags := map[string]*flagValue{}
+	for _, s := range settings {
+		if s.flag != "" {
+			// Settings that default to true or false are switches, -seed=false or just -backup-upload
+			flags[s.flag] = &flagValue{value: s.def, boolean: s.def == "true" || s.def == "false"}
+			fs.Var(flags[s.flag], s.flag, s.usage+" ("+s.env+")")
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+
+	if err := loadFile(*configFile); err != nil {
+		return nil, nil, err
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	c := &Config{}
+	var errs []error
+	for _, s := range settings {
+		v := s.def
+		if env, ok := os.LookupEnv(s.env); ok && env != "" {
+			v = env
+		}
+		if set[s.flag] {
+			v = flags[s.flag].value
+		}
+		if err := s.set(c, strings.TrimSpace(v)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+	return c, fs.Args(), c.validate()
+}
+
+// loadFile puts the settings of a KEY=value file in the environment, without
+// replacing variables that are already set
+func loadFile(path string) error {
+	if path != "" {
+		if err := godotenv.Load(path); err != nil {
+			return fmt.Errorf("config file: %w", err)
+		}
+		return nil
+	}
+	for _, name := range []string{".env", ".venv"} {
+		if _, err := os.Stat(name); err == nil {
+			if err := godotenv.Load(name); err != nil {
+				return fmt.Errorf("config file: %w", err)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// validate checks the settings that depend on each other
+func (c *Config) validate() error {
+	if c.Backup.Every > 0 && c.Backup.Dir == "" {
+		return fmt.Errorf("BACKUP_DIR: snapshots need a directory (or set BACKUP_EVERY=0)")
+	}
+	if c.DBPath == "" {
+		return fmt.Errorf("DB_PATH: must not be empty")
+	}
+	return nil
+}
+
+// ValidateServe checks the settings only serving the site needs, the static
+// assets and the object storage, which the operator commands don't use
+func (c *Config) ValidateServe() error {
+	var errs []error
+	if info, err := os.Stat(c.StaticDir); err != nil {
+		errs = append(errs, fmt.Errorf("STATIC_DIR: %w", err))
+	} else if !info.IsDir() {
+		errs = append(errs, fmt.Errorf("STATIC_DIR: %s is not a directory", c.StaticDir))
+	}
+
+	if c.Storage.Backend == "b2" {
+		var missing []string
+		for _, s := range []struct{ env, value string }{
+			{"B2_KEY_ID", c.Storage.B2KeyID},
+			{"B2_APP_KEY", c.Storage.B2AppKey},
+			{"B2_BUCKET", c.Storage.B2Bucket},
+		} {
+			if s.value == "" {
+				missing = append(missing, s.env)
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("STORAGE_BACKEND: b2 needs %s (or set STORAGE_BACKEND=local)", strings.Join(missing, ", ")))
+		}
+	}
+	if c.Storage.Backend == "local" && c.Storage.Dir == "" {
+		errs = append(errs, fmt.Errorf("STORAGE_DIR: the local backend needs a directory"))
+	}
+	// The local backend serves all of its directory at /files/ without a
+	// login, uploaded snapshots would be public
+	if c.Backup.Upload && c.Storage.Backend == "local" {
+		errs = append(errs, fmt.Errorf("BACKUP_UPLOAD: the local backend serves its files publicly, snapshots stay in BACKUP_DIR"))
+	}
+	return errors.Join(errs...)
+}
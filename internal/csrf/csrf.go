@@ -0,0 +1,54 @@
+// Package csrf carries the per-session CSRF token from the router to the
+// templates and checks it on state-changing requests.
+//
+// The token is sent by HTMX as a header, set once on <body> with hx-headers
+// by views.Layout. Every mutation in the app goes through HTMX, so form
+// fields are not checked: reading them here would parse request bodies
+// before the handlers get to apply their own size limits.
+package csrf
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+)
+
+const HeaderName = "X-CSRF-Token"
+
+type ctxKey struct{}
+
+// WithToken stores the session's token in ctx for the templates.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, token)
+}
+
+// Token returns the token stored by WithToken, "" when there is no session.
+func Token(ctx context.Context) string {
+	token, _ := ctx.Value(ctxKey{}).(string)
+	return token
+}
+
+// Headers is the hx-headers value that makes HTMX send the token.
+func Headers(ctx context.Context) string {
+	data, _ := json.Marshal(map[string]string{HeaderName: Token(ctx)})
+	return string(data)
+}
+
+// Safe reports whether method can't change state and needs no token.
+func Safe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// Valid reports whether r carries the expected token.
+func Valid(r *http.Request, expected string) bool {
+	got := r.Header.Get(HeaderName)
+	if expected == "" || got == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(expected)) == 1
+}
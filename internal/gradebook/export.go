@@ -0,0 +1,172 @@
+package gradebook
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WriteCSV writes the gradebook with one row per student. Text cells that a
+// spreadsheet would take for a formula are prefixed with a quote.
+func (g *Gradebook) WriteCSV(w io.Writer) error {
+	cw := &safeWriter{csv.NewWriter(w)}
+	if err := cw.Write(g.Header()); err != nil {
+		return err
+	}
+
+	for _, row := range g.Rows {
+		record := []string{row.Username, row.LastName, row.FirstName}
+		for _, c := range row.Cells {
+			record = append(record, cellText(c))
+		}
+		record = append(record, FormatAverage(row.Average, row.Graded), strconv.Itoa(row.Missing))
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	averages := []string{"Promedio", "", ""}
+	for j := range g.Assignments {
+		averages = append(averages, FormatAverage(g.Averages[j], g.GradedCount[j]))
+	}
+	if err := cw.Write(averages); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// safeWriter is a csv.Writer that defuses formulas in titles, names and
+// other text typed by users
+type safeWriter struct {
+	*csv.Writer
+}
+
+func (w *safeWriter) Write(record []string) error {
+	safe := make([]string, len(record))
+	for i, v := range record {
+		safe[i] = defuseFormula(v)
+	}
+	return w.Writer.Write(safe)
+}
+
+// decimal is what the exports write as a number: grades and averages. Go
+// also parses Inf, NaN, exponents and hex floats, which spreadsheets don't.
+var decimal = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+func isNumber(v string) bool {
+	return decimal.MatchString(v)
+}
+
+// defuseFormula prefixes v with ' when it starts like a formula. Numbers,
+// negative grades included, are left as they are.
+func defuseFormula(v string) string {
+	if v == "" || !strings.ContainsRune("=+-@\t\r", rune(v[0])) || isNumber(v) {
+		return v
+	}
+	return "'" + v
+}
+
+// WriteXLSX writes the gradebook as a single sheet Office Open XML workbook.
+// Grades and averages are numeric cells so they can be used in formulas.
+func (g *Gradebook) WriteXLSX(w io.Writer) error {
+	var sheet strings.Builder
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	rowNum := 1
+	writeRow := func(cells []string) {
+		fmt.Fprintf(&sheet, `<row r="%d">`, rowNum)
+		for col, value := range cells {
+			ref := columnName(col) + strconv.Itoa(rowNum)
+			if isNumber(value) && col >= 3 {
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+			} else if value != "" {
+				fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, escapeXML(value))
+			}
+		}
+		sheet.WriteString(`</row>`)
+		rowNum++
+	}
+
+	writeRow(g.Header())
+	for _, row := range g.Rows {
+		cells := []string{row.Username, row.LastName, row.FirstName}
+		for _, c := range row.Cells {
+			cells = append(cells, cellText(c))
+		}
+		avg := ""
+		if row.Graded > 0 {
+			avg = FormatAverage(row.Average, row.Graded)
+		}
+		writeRow(append(cells, avg, strconv.Itoa(row.Missing)))
+	}
+	averages := []string{"Promedio", "", ""}
+	for j := range g.Assignments {
+		avg := ""
+		if g.GradedCount[j] > 0 {
+			avg = FormatAverage(g.Averages[j], g.GradedCount[j])
+		}
+		averages = append(averages, avg)
+	}
+	writeRow(averages)
+
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	sheetName := g.Class.Name
+	if sheetName == "" || len([]rune(sheetName)) > 31 || strings.ContainsAny(sheetName, `[]:*?/\`) {
+		sheetName = "Calificaciones"
+	}
+
+	files := []struct{ name, body string }{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + escapeXML(sheetName) + `" sheetId="1" r:id="rId1"/></sheets>` +
+			`</workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// columnName turns a zero based index into a spreadsheet column: 0 → A, 26 → AA.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package gradebook
+
+import (
+	"archive/zip"
+	"bytes"
+	"frontend/database/models"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDefuseFormula(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"", ""},
+		{"Perez", "Perez"},
+		{"85", "85"},
+		{"-5", "-5"},
+		{"72.5", "72.5"},
+		{"=1+1", "'=1+1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"+Inf", "'+Inf"},
+		{"-Inf", "'-Inf"},
+		{"-NaN", "'-NaN"},
+		{"-1e309", "'-1e309"},
+		{"-0x1p4", "'-0x1p4"},
+		{"+1", "'+1"},
+		{"\t=1", "'\t=1"},
+	} {
+		if got := defuseFormula(tt.in); got != tt.want {
+			t.Errorf("defuseFormula(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteXLSXNumbers(t *testing.T) {
+	grades := []string{"85", "-12", "72.5", "Inf", "NaN", "1e309", "0x1p4", "1_000"}
+	g := &Gradebook{
+		Class:       &models.Class{Name: "Clase"},
+		Averages:    make([]float64, len(grades)),
+		GradedCount: make([]int, len(grades)),
+	}
+	row := Row{Username: "u", LastName: "L", FirstName: "F"}
+	for _, grade := range grades {
+		g.Assignments = append(g.Assignments, &models.Assignment{Title: "T"})
+		row.Cells = append(row.Cells, Cell{Grade: grade})
+	}
+	g.Rows = []Row{row}
+
+	var buf bytes.Buffer
+	if err := g.WriteXLSX(&buf); err != nil {
+		t.Fatal(err)
+	}
+	sheet := readZipFile(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+
+	for _, n := range []string{"85", "-12", "72.5"} {
+		if !strings.Contains(sheet, "<v>"+n+"</v>") {
+			t.Errorf("%s is not a numeric cell", n)
+		}
+	}
+	for _, s := range []string{"Inf", "NaN", "1e309", "0x1p4", "1_000"} {
+		if strings.Contains(sheet, "<v>"+s+"</v>") {
+			t.Errorf("%s is a numeric cell", s)
+		}
+		if !strings.Contains(sheet, "<t>"+s+"</t>") {
+			t.Errorf("%s is not a text cell", s)
+		}
+	}
+}
+
+func readZipFile(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
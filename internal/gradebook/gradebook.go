@@ -0,0 +1,165 @@
+package gradebook
+
+import (
+	"frontend/database"
+	"frontend/database/models"
+	"frontend/helper"
+	"frontend/internal/authz"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Cell is the state of one student's work on one assignment.
+type Cell struct {
+	Grade     string // final grade with the late penalty applied, "" if ungraded
+	Submitted bool
+	Late      bool
+	Missing   bool // due date passed and nothing was turned in
+}
+
+type Row struct {
+	Username  string
+	FirstName string
+	LastName  string
+	Cells     []Cell
+	Average   float64 // mean of the graded cells
+	Graded    int     // how many cells have a numeric grade
+	Missing   int
+}
+
+// Gradebook is the students × assignments matrix of a class.
+type Gradebook struct {
+	Class       *models.Class
+	Assignments []*models.Assignment // oldest due date first
+	Rows        []Row
+	Averages    []float64 // per assignment, over graded cells
+	GradedCount []int     // per assignment
+}
+
+// Build loads every assignment and submission of a class into a gradebook.
+func Build(store *database.Store, classId int) (*Gradebook, error) {
+	class, err := database.GetClass(store, classId)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := helper.OrderAssignments(database.ListAssignmentsOfClass(store, classId))
+	slices.Reverse(assignments)
+
+	g := &Gradebook{
+		Class:       class,
+		Assignments: assignments,
+		Averages:    make([]float64, len(assignments)),
+		GradedCount: make([]int, len(assignments)),
+	}
+
+	members, err := database.ListClassMembers(store, classId)
+	if err != nil {
+		return nil, err
+	}
+	for _, username := range members {
+		user, err := database.Get[models.User](store, database.Buckets["users"], username)
+		if err != nil {
+			slog.WarnContext(store.Context(), "gradebook skipping unknown member", slog.String("user", username), telemetry.Err(err))
+			continue
+		}
+		if authz.ClassRole(user, class) != models.RoleStudent {
+			continue
+		}
+		g.Rows = append(g.Rows, Row{
+			Username:  user.Username,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Cells:     make([]Cell, len(assignments)),
+		})
+	}
+	slices.SortFunc(g.Rows, func(a, b Row) int {
+		if c := strings.Compare(a.LastName, b.LastName); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Username, b.Username)
+	})
+
+	sums := make([]float64, len(g.Rows))
+	for j, a := range assignments {
+		submissions, err := database.GetSubmissionsByAssignment(store, classId, a.Id)
+		if err != nil {
+			return nil, err
+		}
+		byUser := make(map[string]*models.Submission, len(submissions))
+		for _, s := range submissions {
+			byUser[s.Username] = s
+		}
+
+		past := helper.GetDateStatus(a.DueDate).Past
+		assignmentSum := 0.0
+
+		for i := range g.Rows {
+			s := byUser[g.Rows[i].Username]
+			cell := Cell{}
+			if s != nil {
+				cell.Grade = helper.FinalGrade(a, s)
+				cell.Submitted = s.SubmittedAt != ""
+				cell.Late = s.Late
+			}
+			cell.Missing = past && !cell.Submitted && cell.Grade == ""
+			g.Rows[i].Cells[j] = cell
+
+			if cell.Missing {
+				g.Rows[i].Missing++
+			}
+			if grade, err := strconv.Atoi(cell.Grade); err == nil {
+				sums[i] += float64(grade)
+				g.Rows[i].Graded++
+				assignmentSum += float64(grade)
+				g.GradedCount[j]++
+			}
+		}
+
+		if g.GradedCount[j] > 0 {
+			g.Averages[j] = assignmentSum / float64(g.GradedCount[j])
+		}
+	}
+
+	for i := range g.Rows {
+		if g.Rows[i].Graded > 0 {
+			g.Rows[i].Average = sums[i] / float64(g.Rows[i].Graded)
+		}
+	}
+
+	return g, nil
+}
+
+// FormatAverage renders an average with one decimal, "–" when nothing was graded.
+func FormatAverage(avg float64, graded int) string {
+	if graded == 0 {
+		return "–"
+	}
+	return strconv.FormatFloat(math.Round(avg*10)/10, 'f', 1, 64)
+}
+
+// Header returns the column titles shared by the exports.
+func (g *Gradebook) Header() []string {
+	header := []string{"Usuario", "Apellido", "Nombre"}
+	for _, a := range g.Assignments {
+		header = append(header, a.Title)
+	}
+	return append(header, "Promedio", "Faltantes")
+}
+
+// cellText is how a cell is written in the exports.
+func cellText(c Cell) string {
+	switch {
+	case c.Grade != "":
+		return c.Grade
+	case c.Missing:
+		return "FALTA"
+	case c.Submitted:
+		return "ENTREGADO"
+	}
+	return ""
+}
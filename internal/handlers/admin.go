@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"frontend/database"
+	"frontend/database/models"
+	"frontend/internal/backup"
+	"frontend/internal/render"
+	"frontend/internal/telemetry"
+	"frontend/templates/body"
+	"frontend/templates/components/admin"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+// adminError shows msg in the admin message area instead of swapping the target.
+func adminError(w http.ResponseWriter, msg string) {
+	w.Header().Set("HX-Retarget", "#admin-msg")
+	w.Header().Set("HX-Reswap", "innerHTML")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(msg))
+}
+
+func renderAdmin(w http.ResponseWriter, r *http.Request, tab string, content templ.Component) {
+	render.RenderWithLayout(w, r, admin.AdminPanel(tab, content), body.Home)
+}
+
+func HandleAdminUsers(store *database.Store, w http.ResponseWriter, r *http.Request, username string) {
+	users, err := database.ListUsers(store)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list users", telemetry.Err(err))
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+
+	renderAdmin(w, r, "usuarios", admin.UsersPanel(users, username))
+}
+
+func HandleAdminUserNew(store *database.Store, w http.ResponseWriter, r *http.Request, username string) {
+	newUsername := strings.TrimSpace(r.FormValue("username"))
+	password := r.FormValue("password")
+	role := r.FormValue("role")
+
+	if !database.ValidName.MatchString(newUsername) {
+		adminError(w, "Usuario inválido: usa letras, números, punto, guion o guion bajo.")
+		return
+	}
+	if password == "" {
+		adminError(w, "La contraseña es obligatoria.")
+		return
+	}
+	if !slices.Contains(database.Roles, role) {
+		adminError(w, "Rol inválido.")
+		return
+	}
+
+	exists, err := database.Exists(store, database.Buckets["users"], newUsername)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		adminError(w, "El usuario "+newUsername+" ya existe.")
+		return
+	}
+
+	err = database.CreateUser(
+		store,
+		newUsername,
+		password,
+		strings.TrimSpace(r.FormValue("first_name")),
+		strings.TrimSpace(r.FormValue("last_name")),
+		role,
+	)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to create user", telemetry.Err(err))
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "user created", slog.String("target", newUsername), slog.String("role", role))
+
+	user, err := database.Get[models.User](store, database.Buckets["users"], newUsername)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	admin.UserRow(user, username).Render(r.Context(), w)
+}
+
+func HandleAdminUserUpdate(store *database.Store, w http.ResponseWriter, r *http.Request, username, target string) {
+	role := r.FormValue("role")
+	if target == username && role != "admin" {
+		adminError(w, "No puedes quitarte el rol de administrador.")
+		return
+	}
+
+	user, err := database.UpdateUser(
+		store,
+		target,
+		strings.TrimSpace(r.FormValue("first_name")),
+		strings.TrimSpace(r.FormValue("last_name")),
+		role,
+	)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to update user", slog.String("target", target), telemetry.Err(err))
+		adminError(w, "No se pudo actualizar a "+target+".")
+		return
+	}
+
+	admin.UserRow(user, username).Render(r.Context(), w)
+}
+
+func HandleAdminUserDisable(store *database.Store, w http.ResponseWriter, r *http.Request, username, target string, disabled bool) {
+	if target == username {
+		adminError(w, "No puedes desactivar tu propia cuenta.")
+		return
+	}
+
+	user, err := database.SetUserDisabled(store, target, disabled)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to change user", slog.String("target", target), telemetry.Err(err))
+		adminError(w, "No se pudo actualizar a "+target+".")
+		return
+	}
+	slog.InfoContext(r.Context(), "user status changed", slog.String("target", target), slog.Bool("disabled", disabled))
+
+	if disabled {
+		if _, err := database.RevokeUserSessions(store, target, ""); err != nil {
+			slog.WarnContext(r.Context(), "failed to revoke sessions", slog.String("target", target), telemetry.Err(err))
+		}
+	}
+
+	admin.UserRow(user, username).Render(r.Context(), w)
+}
+
+func HandleAdminClasses(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	classes, err := database.ListClasses(store)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	subjects, err := database.ListSubjects(store)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	users, err := database.ListUsers(store)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+
+	renderAdmin(w, r, "clases", admin.ClassesPanel(classes, subjects, users))
+}
+
+func HandleAdminClassNew(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.FormValue("name"))
+	subject := r.FormValue("subject")
+	if name == "" {
+		adminError(w, "El nombre de la clase es obligatorio.")
+		return
+	}
+
+	exists, err := database.Exists(store, database.Buckets["subjects"], subject)
+	if err != nil || !exists {
+		adminError(w, "Selecciona una materia existente.")
+		return
+	}
+
+	class, err := database.CreateClass(store, name, strings.TrimSpace(r.FormValue("description")), subject)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to create class", telemetry.Err(err))
+		http.Error(w, "Failed to create class", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "class created", slog.Int("class", class.Id), slog.String("name", class.Name))
+
+	renderClassCard(store, w, r, class)
+}
+
+// HandleAdminClassMember enrolls (or removes) the posted username in a class.
+func HandleAdminClassMember(store *database.Store, w http.ResponseWriter, r *http.Request, classId int, enroll bool) {
+	var err error
+	member := r.FormValue("username")
+	if enroll {
+		exists, err := database.Exists(store, database.Buckets["users"], member)
+		if err != nil || !exists {
+			adminError(w, "Selecciona un usuario existente.")
+			return
+		}
+		err = database.AddUserToClass(store, classId, member)
+	} else {
+		err = database.RemoveUserFromClass(store, classId, member)
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to change class members", slog.Int("class", classId), telemetry.Err(err))
+		adminError(w, "No se pudo actualizar la clase.")
+		return
+	}
+	slog.InfoContext(r.Context(), "class membership changed", slog.Int("class", classId), slog.String("member", member), slog.Bool("enrolled", enroll))
+
+	class, err := database.GetClass(store, classId)
+	if err != nil {
+		http.Error(w, "Class not found", http.StatusNotFound)
+		return
+	}
+	renderClassCard(store, w, r, class)
+}
+
+// HandleAdminClassRole makes a member professor or student in one class.
+func HandleAdminClassRole(store *database.Store, w http.ResponseWriter, r *http.Request, classId int) {
+	member := r.FormValue("username")
+	role := r.FormValue("role")
+	if err := database.SetClassRole(store, classId, member, role); err != nil {
+		slog.ErrorContext(r.Context(), "failed to set class role", slog.Int("class", classId), slog.String("member", member), telemetry.Err(err))
+		adminError(w, "No se pudo cambiar el rol de "+member+".")
+		return
+	}
+	slog.InfoContext(r.Context(), "class role changed", slog.Int("class", classId), slog.String("member", member), slog.String("role", role))
+
+	class, err := database.GetClass(store, classId)
+	if err != nil {
+		http.Error(w, "Class not found", http.StatusNotFound)
+		return
+	}
+	renderClassCard(store, w, r, class)
+}
+
+func renderClassCard(store *database.Store, w http.ResponseWriter, r *http.Request, class *models.Class) {
+	users, err := database.ListUsers(store)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	admin.ClassCard(class, users).Render(r.Context(), w)
+}
+
+func HandleAdminSubjects(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	subjects, err := database.ListSubjects(store)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+
+	classes := make(map[string][]*models.Class, len(subjects))
+	for _, subject := range subjects {
+		classes[subject.InternalName], err = database.ListClassesForSubject(store, subject.InternalName)
+		if err != nil {
+			http.Error(w, "Server database error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	renderAdmin(w, r, "materias", admin.SubjectsPanel(subjects, classes))
+}
+
+func HandleAdminSubjectNew(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	internalName := strings.ToLower(strings.TrimSpace(r.FormValue("internal_name")))
+	name := strings.TrimSpace(r.FormValue("name"))
+	if !database.ValidName.MatchString(internalName) || name == "" {
+		adminError(w, "Identificador o nombre de materia inválido.")
+		return
+	}
+
+	exists, err := database.Exists(store, database.Buckets["subjects"], internalName)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		adminError(w, "La materia "+internalName+" ya existe.")
+		return
+	}
+
+	if err := database.CreateSubject(store, internalName, name); err != nil {
+		http.Error(w, "Failed to create subject", http.StatusInternalServerError)
+		return
+	}
+
+	admin.SubjectRow(&models.Subject{InternalName: internalName, Name: name}, nil).Render(r.Context(), w)
+}
+
+func HandleAdminLockouts(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	locked, err := database.ListLockedLogins(store, time.Now())
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+
+	renderAdmin(w, r, "bloqueos", admin.LockoutsPanel(locked))
+}
+
+func HandleAdminUnlock(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	key := r.FormValue("key")
+	if err := database.UnlockLogin(store, key); err != nil {
+		slog.ErrorContext(r.Context(), "failed to unlock login", slog.String("key", key), telemetry.Err(err))
+		adminError(w, "No se pudo desbloquear.")
+		return
+	}
+	slog.InfoContext(r.Context(), "login unlocked", slog.String("key", key))
+
+	// The row is removed by swapping in nothing
+	w.WriteHeader(http.StatusOK)
+}
+
+func HandleAdminBackups(w http.ResponseWriter, r *http.Request, dir string) {
+	snapshots, err := backup.List(dir)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list backups", telemetry.Err(err))
+		http.Error(w, "Failed to list backups", http.StatusInternalServerError)
+		return
+	}
+
+	renderAdmin(w, r, "copias", admin.BackupsPanel(snapshots))
+}
+
+// HandleAdminBackupDownload streams a snapshot of the database taken now
+func HandleAdminBackupDownload(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	name := backup.FileName(time.Now())
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+
+	n, err := database.WriteBackup(store, w)
+	if err != nil {
+		// Headers are gone by now, the truncated download is all we can do
+		slog.ErrorContext(r.Context(), "backup download failed", telemetry.Err(err))
+		return
+	}
+	slog.InfoContext(r.Context(), "backup downloaded", slog.Int64("bytes", n))
+}
+
+// HandleAdminBackupFile serves one of the scheduled snapshots in dir
+func HandleAdminBackupFile(w http.ResponseWriter, r *http.Request, dir, name string) {
+	snapshot, err := backup.Find(dir, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+snapshot.Name+`"`)
+	slog.InfoContext(r.Context(), "backup downloaded", slog.String("file", snapshot.Name))
+	http.ServeFile(w, r, snapshot.Path)
+}
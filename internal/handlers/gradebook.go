@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"frontend/database"
+	"frontend/internal/gradebook"
+	"frontend/internal/render"
+	"frontend/internal/telemetry"
+	"frontend/templates/body"
+	"frontend/templates/components/gradebook/gradebookTable"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+func HandleGradebook(store *database.Store, w http.ResponseWriter, r *http.Request, classId int) {
+	g, err := gradebook.Build(store, classId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to build gradebook", telemetry.Err(err))
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+
+	render.RenderWithLayout(w, r, gradebookTable.GradebookTable(g), body.Home)
+}
+
+// HandleGradebookExport downloads the gradebook as ?format=csv (default) or xlsx.
+func HandleGradebookExport(store *database.Store, w http.ResponseWriter, r *http.Request, classId int) {
+	g, err := gradebook.Build(store, classId)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to build gradebook", telemetry.Err(err))
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("calificaciones-%d-%s", classId, time.Now().Format("2006-01-02"))
+
+	switch r.URL.Query().Get("format") {
+	case "", "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		// BOM so spreadsheet programs read the accents as UTF-8
+		w.Write([]byte("\ufeff"))
+		err = g.WriteCSV(w)
+	case "xlsx":
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.xlsx"`)
+		err = g.WriteXLSX(w)
+	default:
+		http.Error(w, "Unknown format", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		slog.WarnContext(r.Context(), "failed to write gradebook export", telemetry.Err(err))
+	}
+}
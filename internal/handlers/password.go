@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"frontend/auth"
+	"frontend/database"
+	"frontend/database/models"
+	"frontend/internal/render"
+	"frontend/internal/telemetry"
+	"frontend/templates/body"
+	"frontend/templates/components/account"
+	"frontend/templates/components/admin"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// checkNewPassword returns a message for the user when password can't be used, "" otherwise.
+func checkNewPassword(password, confirm string) string {
+	if len(password) < auth.MinPasswordLength {
+		return "La contraseña debe tener al menos " + strconv.Itoa(auth.MinPasswordLength) + " caracteres."
+	}
+	if password != confirm {
+		return "Las contraseñas no coinciden."
+	}
+	return ""
+}
+
+func HandlePasswordPage(w http.ResponseWriter, r *http.Request) {
+	render.RenderWithLayout(w, r, account.PasswordPanel(), body.Home)
+}
+
+// HandlePasswordChange changes the password of the logged in user and closes
+// their other sessions, keeping the one that made the change.
+func HandlePasswordChange(store *database.Store, w http.ResponseWriter, r *http.Request, username, sessionID string) {
+	user, err := database.Get[models.User](store, database.Buckets["users"], username)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+
+	if !auth.CheckPassword(user.PasswordHashed, r.FormValue("current")) {
+		account.PasswordMessage("La contraseña actual es incorrecta.", false).Render(r.Context(), w)
+		return
+	}
+
+	password := r.FormValue("password")
+	if msg := checkNewPassword(password, r.FormValue("confirm")); msg != "" {
+		account.PasswordMessage(msg, false).Render(r.Context(), w)
+		return
+	}
+
+	if err := database.SetPassword(store, username, password); err != nil {
+		slog.ErrorContext(r.Context(), "failed to change password", telemetry.Err(err))
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	if _, err := database.RevokeUserSessions(store, username, sessionID); err != nil {
+		slog.WarnContext(r.Context(), "failed to revoke sessions", telemetry.Err(err))
+	}
+	slog.InfoContext(r.Context(), "password changed")
+
+	account.PasswordMessage("Contraseña actualizada.", true).Render(r.Context(), w)
+}
+
+// HandlePasswordResetPage serves the page behind an admin issued reset link.
+// It is reachable without a session; the token is the credential.
+func HandlePasswordResetPage(store *database.Store, w http.ResponseWriter, r *http.Request, token string) {
+	reset, err := database.GetResetToken(store, token)
+	if err != nil || reset == nil {
+		token = ""
+	}
+	render.RenderWithLayout(w, r, body.Reset(token))
+}
+
+// HandlePasswordReset sets the new password and burns the token.
+func HandlePasswordReset(store *database.Store, w http.ResponseWriter, r *http.Request, token string) {
+	password := r.FormValue("password")
+	if msg := checkNewPassword(password, r.FormValue("confirm")); msg != "" {
+		w.Write([]byte(msg))
+		return
+	}
+
+	username, err := database.UseResetToken(store, token, password)
+	if err != nil {
+		slog.WarnContext(r.Context(), "password reset failed", telemetry.Err(err))
+		w.Write([]byte("El enlace no es válido o ya fue usado."))
+		return
+	}
+
+	// Whoever had the old password loses access right away
+	if _, err := database.RevokeUserSessions(store, username, ""); err != nil {
+		slog.WarnContext(r.Context(), "failed to revoke sessions", telemetry.Err(err))
+	}
+	slog.InfoContext(r.Context(), "password reset", slog.String("target", username))
+
+	w.Header().Set("HX-Redirect", "/login")
+}
+
+// HandleAdminUserReset issues a one-time reset link for target, under baseURL,
+// and shows it to the admin.
+func HandleAdminUserReset(store *database.Store, w http.ResponseWriter, r *http.Request, username, target, baseURL string) {
+	token, err := database.CreateResetToken(store, target, username)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to create reset token", slog.String("target", target), telemetry.Err(err))
+		adminError(w, "No se pudo generar el enlace para "+target+".")
+		return
+	}
+	slog.InfoContext(r.Context(), "reset link issued", slog.String("target", target))
+
+	link := baseURL + "/login/reset/" + token
+
+	admin.ResetLink(target, link).Render(r.Context(), w)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"frontend/database"
+	"frontend/internal/telemetry"
+	"frontend/templates/components/admin"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// roleAliases lets the roster use the Spanish role names shown in the UI.
+var roleAliases = map[string]string{
+	"estudiante":    "student",
+	"profesor":      "professor",
+	"administrador": "admin",
+}
+
+func HandleAdminImport(w http.ResponseWriter, r *http.Request) {
+	renderAdmin(w, r, "importar", admin.ImportPanel())
+}
+
+// HandleAdminImportRun previews or commits a CSV roster with the columns
+// username, first name, last name, role, class id.
+func HandleAdminImportRun(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 2<<20)
+	if err := r.ParseMultipartForm(2 << 20); err != nil {
+		adminError(w, "No se pudo leer el archivo (máximo 2 MB).")
+		return
+	}
+
+	file, _, err := r.FormFile("roster")
+	if err != nil {
+		adminError(w, "Selecciona un archivo CSV.")
+		return
+	}
+	defer file.Close()
+
+	rows, parseErrors, err := parseRoster(file)
+	if err != nil {
+		slog.InfoContext(r.Context(), "invalid roster", telemetry.Err(err))
+		adminError(w, "El archivo no es un CSV válido.")
+		return
+	}
+	if len(rows) == 0 && len(parseErrors) == 0 {
+		adminError(w, "El archivo no tiene filas.")
+		return
+	}
+
+	// Rows that could not even be read make the whole import a preview
+	dryRun := r.FormValue("mode") != "commit" || len(parseErrors) > 0
+
+	results, committed, err := database.ImportRoster(store, rows, dryRun)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "roster import failed", telemetry.Err(err))
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	results = append(parseErrors, results...)
+	slices.SortFunc(results, func(a, b database.RosterResult) int {
+		return a.Row.Line - b.Row.Line
+	})
+	slog.InfoContext(r.Context(), "roster processed", slog.Int("rows", len(results)), slog.Bool("committed", committed))
+
+	admin.ImportReport(results, committed).Render(r.Context(), w)
+}
+
+// parseRoster reads the CSV rows, skipping an optional header line. Lines
+// that cannot be parsed are returned as results carrying their error.
+func parseRoster(r io.Reader) ([]database.RosterRow, []database.RosterResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []database.RosterRow
+	var parseErrors []database.RosterResult
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if line == 1 && len(record) > 0 {
+			header := strings.ToLower(strings.TrimPrefix(record[0], "\ufeff"))
+			if header == "username" || header == "usuario" {
+				continue
+			}
+		}
+
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue // blank line
+		}
+
+		row := database.RosterRow{Line: line}
+		if len(record) != 5 {
+			parseErrors = append(parseErrors, database.RosterResult{
+				Row: row,
+				Err: fmt.Sprintf("se esperaban 5 columnas, hay %d", len(record)),
+			})
+			continue
+		}
+
+		row.Username = strings.TrimSpace(record[0])
+		row.FirstName = strings.TrimSpace(record[1])
+		row.LastName = strings.TrimSpace(record[2])
+		row.Role = strings.ToLower(strings.TrimSpace(record[3]))
+		if alias, ok := roleAliases[row.Role]; ok {
+			row.Role = alias
+		}
+
+		classId, err := strconv.Atoi(strings.TrimSpace(record[4]))
+		if err != nil {
+			parseErrors = append(parseErrors, database.RosterResult{
+				Row: row,
+				Err: fmt.Sprintf("id de clase inválido %q", record[4]),
+			})
+			continue
+		}
+		row.ClassId = classId
+
+		if !database.ValidName.MatchString(row.Username) {
+			parseErrors = append(parseErrors, database.RosterResult{
+				Row: row,
+				Err: fmt.Sprintf("usuario inválido %q", row.Username),
+			})
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, parseErrors, nil
+}
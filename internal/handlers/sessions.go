@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"frontend/database"
+	"frontend/internal/render"
+	"frontend/internal/telemetry"
+	"frontend/templates/body"
+	"frontend/templates/components/sessions"
+	"log/slog"
+	"net/http"
+)
+
+func HandleSessions(store *database.Store, w http.ResponseWriter, r *http.Request, username, sessionID string) {
+	list, err := database.ListUserSessions(store, username)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list sessions", telemetry.Err(err))
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+
+	render.RenderWithLayout(w, r, sessions.SessionsPanel(list, database.SessionKey(sessionID)), body.Home)
+}
+
+// HandleSessionRevoke closes another session of the same user. The router
+// handles revoking the current one, which is a logout.
+func HandleSessionRevoke(store *database.Store, w http.ResponseWriter, r *http.Request, username, sessionID, key string) {
+	if err := database.RevokeSession(store, username, key); err != nil {
+		slog.InfoContext(r.Context(), "failed to revoke session", telemetry.Err(err))
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	slog.InfoContext(r.Context(), "session revoked")
+
+	// The row is removed by swapping in nothing
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleSessionRevokeOthers logs the user out everywhere except this browser.
+func HandleSessionRevokeOthers(store *database.Store, w http.ResponseWriter, r *http.Request, username, sessionID string) {
+	removed, err := database.RevokeUserSessions(store, username, sessionID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to revoke sessions", telemetry.Err(err))
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "other sessions revoked", slog.Int("count", removed))
+
+	list, err := database.ListUserSessions(store, username)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	sessions.SessionsPanel(list, database.SessionKey(sessionID)).Render(r.Context(), w)
+}
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"frontend/database"
 	"frontend/database/models"
 	"frontend/helper"
 	"frontend/internal/render"
+	"frontend/internal/telemetry"
 	"frontend/storage"
 	"frontend/templates/body"
 	"frontend/templates/components/assignment/assignmentDetail"
@@ -15,13 +17,35 @@ import (
 	"frontend/templates/components/assignment/studentSubmissionSlot"
 	"frontend/templates/components/assignment/submissionDetail"
 	"frontend/templates/components/assignment/submissionEditor"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/a-h/templ"
 )
 
+// parseMultipart parses an upload form, keeping up to 32 MB in memory. It
+// answers 413 itself when the body is over the configured upload limit and
+// 400 when it can't be read.
+func parseMultipart(w http.ResponseWriter, r *http.Request) bool {
+	err := r.ParseMultipartForm(32 << 20)
+	if err == nil {
+		return true
+	}
+	var tooBig *http.MaxBytesError
+	if errors.As(err, &tooBig) {
+		slog.WarnContext(r.Context(), "upload too large", slog.Int64("limit", tooBig.Limit))
+		http.Error(w, "Upload too large", http.StatusRequestEntityTooLarge)
+		return false
+	}
+	slog.WarnContext(r.Context(), "failed to parse multipart form", telemetry.Err(err))
+	http.Error(w, "Failed to parse form", http.StatusBadRequest)
+	return false
+}
+
 func HandleSubmissionDefault(
 	store *database.Store,
 	w http.ResponseWriter,
@@ -29,20 +53,9 @@ func HandleSubmissionDefault(
 	classId int,
 	professor bool,
 	username string) {
-	fmt.Println("📥 [HandleSubmissionDefault] Request received")
-
-	if !professor {
-		fmt.Println("Acces denied")
-		http.Error(w, "Acces denied", http.StatusBadRequest)
-		return
-	}
-
 	assignments := database.ListAssignmentsOfClass(store, classId)
 
-	assignments, err := helper.OrderAssignments(assignments)
-	if err != nil {
-		fmt.Println("Error ordering assignments:", err)
-	}
+	assignments = helper.OrderAssignments(assignments)
 
 	render.RenderWithLayout(
 		w, r,
@@ -62,6 +75,7 @@ func HandleSubmissionDefault(
 				false,
 			),
 			submissionDetail.SubmissionDetail(
+				nil,
 				nil,
 				"",
 				"",
@@ -72,270 +86,332 @@ func HandleSubmissionDefault(
 	)
 }
 
-func HandleAssignmentSubmissions(store *database.Store, w http.ResponseWriter, r *http.Request, professor bool) {
-	fmt.Println("📥 [HandleAssignmentSubmissions] Request received")
-
-	path := strings.Trim(r.URL.Path, "/")
-	parts := strings.Split(path, "/")
-	helper.PrintArray(parts)
-
-	if professor {
-		classIdInt, err := strconv.Atoi(parts[0])
-		if err != nil {
-			fmt.Println("! Invalid class Id:", parts[0])
-			http.Error(w, "Invalid class Id", http.StatusBadRequest)
-			return
-		}
-
-		assignment, err := database.GetWithPrefix[models.Assignment](store, database.Buckets["assignments"], parts[2], parts[0])
+// HandleAssignmentSubmissions lists the submissions of an assignment for its professor
+func HandleAssignmentSubmissions(store *database.Store, w http.ResponseWriter, r *http.Request, classId, assignmentId int) {
+	assignment, err := database.GetWithPrefix[models.Assignment](store, database.Buckets["assignments"], strconv.Itoa(assignmentId), strconv.Itoa(classId))
+	if err != nil {
+		slog.WarnContext(r.Context(), "assignment not found", telemetry.Err(err))
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	dateStatus := helper.GetDateStatus(assignment.DueDate)
+
+	// Submissions are only listed once the due date has passed
+	var submissions []*models.Submission
+	if !dateStatus.Past {
+		assignment = nil
+	} else {
+		submissions, err = database.GetSubmissionsByAssignment(store, classId, assignmentId)
 		if err != nil {
-			fmt.Println("Error fetching assignment: %w", err)
+			slog.ErrorContext(r.Context(), "failed to list submissions", telemetry.Err(err))
 			http.Error(w, "Server database error", http.StatusInternalServerError)
 			return
 		}
-		dateStatus, err := helper.GetDateStatus(assignment.DueDate)
-		if err != nil {
-			fmt.Println("Error calculating date status of assignment: %w", err)
-			http.Error(w, "Error calculating date status of assignment", http.StatusInternalServerError)
-			return
-		}
-
-		var submissions []*models.Submission
-		if dateStatus.Past {
-			assignment = nil
-		} else {
-			submissions, err = database.ListByPrefix[models.Submission](store, database.Buckets["submissions"], parts[0], parts[2])
-			if err != nil {
-				fmt.Println("Error fetching submissions: %w", err)
-				http.Error(w, "Server database error", http.StatusInternalServerError)
-				return
-			}
-		}
-
-		fmt.Println("→ Rendering professor submissions list")
-		assignmentDetailProfessor.AssignmentDetailProfessor(classIdInt, assignment, submissions, dateStatus.Past).Render(r.Context(), w)
-		submissionDetail.SubmissionDetail(nil, "", "", false, false).Render(r.Context(), w)
-		fmt.Println("✔ Render complete")
-		return
 	}
-}
 
-func HandleAssignmentSubmission(store *database.Store, w http.ResponseWriter, r *http.Request, username string, professor bool) {
-	fmt.Println("📥 [HandleAssignmentSubmission] Request received")
+	assignmentDetailProfessor.AssignmentDetailProfessor(classId, assignment, submissions, !dateStatus.Past).Render(r.Context(), w)
+	submissionDetail.SubmissionDetail(nil, nil, "", "", false, false).Render(r.Context(), w)
+}
 
-	path := strings.Trim(r.URL.Path, "/")
-	parts := strings.Split(path, "/")
+// HandleAssignmentSubmission shows the submission of student: the grading view
+// for professors, the editor or read-only detail for the student themself.
+func HandleAssignmentSubmission(store *database.Store, w http.ResponseWriter, r *http.Request, classId, assignmentId int, student, username string, professor bool) {
+	classIdStr, assignmentIdStr := strconv.Itoa(classId), strconv.Itoa(assignmentId)
 
-	classIdInt, err := strconv.Atoi(parts[0])
+	submission, err := database.GetSubmission(store, classId, assignmentId, student)
 	if err != nil {
-		fmt.Println("! Invalid class Id:", parts[0])
-		http.Error(w, "Invalid class Id", http.StatusBadRequest)
-		return
+		// Nothing turned in yet: show an empty submission instead of failing
+		slog.DebugContext(r.Context(), "no submission stored", slog.String("student", student), telemetry.Err(err))
+		submission = &models.Submission{Username: student, Content: []string{}}
 	}
 
-	fmt.Printf("  > Class: %d | Assignment: %s | Professor: %v\n", classIdInt, parts[0], professor)
-
-	submission, err := database.GetWithPrefix[models.Submission](store, database.Buckets["submissions"], parts[4], parts[0], parts[2])
+	assignment, err := database.GetWithPrefix[models.Assignment](store, database.Buckets["assignments"], assignmentIdStr, classIdStr)
 	if err != nil {
-		fmt.Println("Error fetching submission: %w", err)
+		slog.WarnContext(r.Context(), "assignment not found", telemetry.Err(err))
 		http.Error(w, "Server database error", http.StatusInternalServerError)
 		return
 	}
-	fmt.Printf("  ✓ Assignment loaded: %+v\n", submission)
 
 	if professor {
-		fmt.Println("  → Rendering professor detail")
-		submissionDetail.SubmissionDetail(submission, parts[0], parts[2], professor, false).Render(r.Context(), w)
-		fmt.Println("  ✔ Render complete")
+		submissionDetail.SubmissionDetail(submission, assignment, classIdStr, assignmentIdStr, professor, false).Render(r.Context(), w)
 		return
 	}
 
-	if username == parts[4] {
-		fmt.Println("  → Rendering student detail")
-		assignment, err := database.GetWithPrefix[models.Assignment](store, database.Buckets["assignments"], parts[2], parts[0])
-		if err != nil {
-			fmt.Println("Error fetching assignment info: %w", err)
-			http.Error(w, "Server database error", http.StatusInternalServerError)
-			return
-		}
-
-		arguments, err := helper.StringsToInts(parts[0], parts[2])
-		if err != nil {
-			fmt.Println("Invalid arguments: %w", err)
-			http.Error(w, "Invalid arguments", http.StatusBadRequest)
-			return
-		}
+	if username == student {
 
-		status, err := helper.GetDateStatus(assignment.DueDate)
-		if err != nil {
-			fmt.Println("Invalid due date: %w", err)
-			http.Error(w, "Invalid due date", http.StatusBadRequest)
-			return
-		}
+		editable := helper.AcceptsSubmission(assignment, time.Now())
 
+		// Students can keep editing while the late policy accepts work, afterwards it is read-only
 		var detailWindow templ.Component
-		if status.Past {
-			detailWindow = submissionEditor.SubmissionEditor(submission, arguments[0], arguments[1], assignment.Title)
+		if editable {
+			detailWindow = submissionEditor.SubmissionEditor(submission, classId, assignmentId, assignment.Title)
 		} else {
-			detailWindow = submissionDetail.SubmissionDetail(submission, parts[0], parts[2], false, false)
+			detailWindow = submissionDetail.SubmissionDetail(submission, assignment, classIdStr, assignmentIdStr, false, false)
 		}
 		assignmentDetailWindow := assignmentDetail.AssignmentDetail(assignment, false)
 
 		detailWindow.Render(r.Context(), w)
 		assignmentDetailWindow.Render(r.Context(), w)
-		fmt.Println("  ✔ Render complete")
 		return
 	}
 }
 
-func HandleSubmissionGrade(store *database.Store, w http.ResponseWriter, r *http.Request, classId int, username string, professor bool) {
-	if !professor {
-		fmt.Println("Not allowed")
-		http.Error(w, "Not allowed", http.StatusBadRequest)
+// HandleSubmissionGrade stores the grade, written feedback and annotated files
+// of a student's submission, recording which professor graded it.
+func HandleSubmissionGrade(store *database.Store, storage storage.Storage, w http.ResponseWriter, r *http.Request, classId, assignmentId int, username, grader string) {
+	assignment, err := database.GetWithPrefix[models.Assignment](store, database.Buckets["assignments"], strconv.Itoa(assignmentId), strconv.Itoa(classId))
+	if err != nil {
+		slog.WarnContext(r.Context(), "assignment not found", telemetry.Err(err))
+		http.Error(w, "Assignment not found", http.StatusNotFound)
 		return
 	}
 
-	path := strings.Trim(r.URL.Path, "/")
-	parts := strings.Split(path, "/")
-
-	assignmentId, err := strconv.Atoi(parts[2])
-	if err != nil {
-		fmt.Printf("Invalid assignment Id: %v\n", err)
-		http.Error(w, "Invalid assignment Id", http.StatusBadRequest)
+	if !parseMultipart(w, r) {
 		return
 	}
 
 	grade := r.FormValue("grade")
+	feedback := strings.TrimSpace(r.FormValue("feedback"))
+
+	// With a rubric the grade is computed here from the chosen levels
+	var rubricScores []int
+	if assignment.Rubric != nil {
+		rubricScores = make([]int, len(assignment.Rubric.Criteria))
+		for i := range rubricScores {
+			level, err := strconv.Atoi(r.FormValue(fmt.Sprintf("criterion_%d", i)))
+			if err != nil {
+				http.Error(w, "Selecciona un nivel para cada criterio", http.StatusBadRequest)
+				return
+			}
+			rubricScores[i] = level
+		}
+
+		points, err := helper.ScoreRubric(assignment.Rubric, rubricScores)
+		if err != nil {
+			slog.WarnContext(r.Context(), "invalid rubric scores", telemetry.Err(err))
+			http.Error(w, "Invalid rubric scores", http.StatusBadRequest)
+			return
+		}
+		grade = helper.RubricGrade(points, helper.RubricMax(assignment.Rubric))
+	}
+	// Checked before touching storage, so a bad grade doesn't cost any files
+	if err := database.CheckGrade(grade); err != nil {
+		http.Error(w, fmt.Sprintf("La nota debe ser un número de 0 a %d", database.MaxGrade), http.StatusBadRequest)
+		return
+	}
+	keep := r.Form["keep[]"]
+	uploads := r.MultipartForm.File["uploads"]
 
-	submission, err := database.GradeSubmission(store, classId, assignmentId, username, grade)
+	previous, err := database.GetSubmission(store, classId, assignmentId, username)
 	if err != nil {
-		fmt.Println("Database error grading: %w", err)
-		http.Error(w, "Database error grading", http.StatusBadRequest)
+		slog.WarnContext(r.Context(), "submission not found", slog.String("student", username), telemetry.Err(err))
+		http.Error(w, "Submission not found", http.StatusNotFound)
 		return
 	}
 
-	fmt.Println("→ Rendering Student Submission Slot")
-	studentSubmissionSlot.StudentSubmissionSlot(classId, assignmentId, submission).Render(r.Context(), w)
-	fmt.Println("✔ Render complete")
-}
+	// Feedback files work like the submission files: keep[] plus new uploads.
+	// Only files already attached can be kept, keep[] can't add other URLs.
+	feedbackFiles := []string{}
+	for _, k := range keep {
+		if slices.Contains(previous.FeedbackFiles, k) && !slices.Contains(feedbackFiles, k) {
+			feedbackFiles = append(feedbackFiles, k)
+		}
+	}
 
-// HandleSubmissionUpdate updates a submission based on form data (HTMX-friendly)
-func HandleSubmissionUpdate(store *database.Store, storage *storage.B2Storage, w http.ResponseWriter, r *http.Request, classId int, assignmentId, username string, professor bool) {
-	fmt.Println("📥 [HandleSubmissionUpdate] Request received")
+	var uploaded []string
+	for _, f := range uploads {
+		file, err := f.Open()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to open uploaded file", slog.String("file", f.Filename), telemetry.Err(err))
+			http.Error(w, "Failed to open uploaded file", http.StatusInternalServerError)
+			return
+		}
 
-	if professor {
-		http.Error(w, "Not allowed", http.StatusBadRequest)
-		return
+		safeName := helper.NormalizeFilename(f.Filename)
+		key := fmt.Sprintf("feedback/%d/%d/%s/%s", classId, assignmentId, username, safeName)
+
+		fileURL, err := storage.UploadFile(r.Context(), key, file)
+		_ = file.Close()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to upload file", slog.String("file", f.Filename), telemetry.Err(err))
+			http.Error(w, "Failed to upload file", http.StatusInternalServerError)
+			return
+		}
+
+		slog.DebugContext(r.Context(), "uploaded feedback file", slog.String("url", fileURL))
+		uploaded = append(uploaded, fileURL)
+		if !slices.Contains(feedbackFiles, fileURL) {
+			feedbackFiles = append(feedbackFiles, fileURL)
+		}
 	}
 
-	assignmentIdInt, err := strconv.Atoi(assignmentId)
+	gradedAt := time.Now().In(database.ClassLocation(store, classId)).Format(time.RFC3339)
+
+	submission, err := database.GradeSubmission(store, classId, assignmentId, username, database.Grading{
+		Grade:         grade,
+		Feedback:      feedback,
+		FeedbackFiles: feedbackFiles,
+		RubricScores:  rubricScores,
+		GradedBy:      grader,
+		GradedAt:      gradedAt,
+	})
 	if err != nil {
-		http.Error(w, "Invalid assignment Id", http.StatusBadRequest)
+		slog.WarnContext(r.Context(), "failed to grade submission", telemetry.Err(err))
+		// Nothing points to the new uploads, unless they replaced a file
+		// that was already attached
+		for _, url := range uploaded {
+			if !slices.Contains(previous.FeedbackFiles, url) {
+				_ = storage.DeleteFile(r.Context(), url)
+			}
+		}
+		http.Error(w, "Database error grading", http.StatusBadRequest)
 		return
 	}
 
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+	// The grade is saved, the files it dropped can go
+	for _, oldUrl := range previous.FeedbackFiles {
+		if !slices.Contains(feedbackFiles, oldUrl) {
+			if err := storage.DeleteFile(r.Context(), oldUrl); err != nil {
+				slog.WarnContext(r.Context(), "failed to delete old feedback file", slog.String("url", oldUrl), telemetry.Err(err))
+			}
+		}
 	}
 
+	studentSubmissionSlot.StudentSubmissionSlot(classId, assignment, submission).Render(r.Context(), w)
+}
+
+// HandleSubmissionUpdate updates a submission based on form data (HTMX-friendly)
+func HandleSubmissionUpdate(store *database.Store, storage storage.Storage, w http.ResponseWriter, r *http.Request, classId, assignmentId int, username string) {
 	if username == "" {
 		http.Error(w, "Missing submission username", http.StatusBadRequest)
 		return
 	}
-	fmt.Printf("👉 Submission Username: %s | Class ID: %d | Assignment ID: %d\n", username, classId, assignmentIdInt)
 
 	// Parse form
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		fmt.Printf("❌ Failed to parse multipart form: %v\n", err)
-		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+	if !parseMultipart(w, r) {
 		return
 	}
-	fmt.Println("✅ Multipart form parsed successfully")
 
 	// Parse values
 	description := r.FormValue("description")
 	keep := r.Form["keep[]"]
 	uploads := r.MultipartForm.File["uploads"]
 
-	fmt.Println("👉 Parsed form values:")
-	fmt.Printf("   - Description: %q\n", description)
-	fmt.Printf("   - Keep[]: %+v\n", keep)
-	fmt.Printf("   - Uploads count: %d\n", len(uploads))
+	slog.DebugContext(r.Context(), "submission form",
+		slog.Int("assignment", assignmentId),
+		slog.Any("keep", keep),
+		slog.Int("uploads", len(uploads)),
+	)
 
-	// 1. Load submission
-	submissionModel, err := database.GetWithPrefix[models.Submission](
+	// 1. Make sure the assignment exists before accepting anything for it
+	assignment, err := database.GetWithPrefix[models.Assignment](
 		store,
-		database.Buckets["submissions"],
-		username, // primary key
-		fmt.Sprintf("%d:%d", classId, assignmentIdInt), // prefix: class+assignment
+		database.Buckets["assignments"],
+		strconv.Itoa(assignmentId),
+		strconv.Itoa(classId),
 	)
-	if err != nil || submissionModel == nil {
-		fmt.Printf("❌ Submission not found for username=%s: %v\n", username, err)
-		http.Error(w, "Submission not found", http.StatusNotFound)
+	if err != nil {
+		slog.WarnContext(r.Context(), "assignment not found", slog.Int("assignment", assignmentId), telemetry.Err(err))
+		http.Error(w, "Assignment not found", http.StatusNotFound)
 		return
 	}
-	fmt.Printf("✅ Loaded submission: %+v\n", submissionModel)
-
-	// 2. Build new Content
-	var newContent []string
-	newContent = append(newContent, keep...)
 
-	keepSet := make(map[string]struct{})
-	for _, k := range keep {
-		keepSet[k] = struct{}{}
+	// 1b. Enforce the late policy before storing any file
+	// Stamped in the school's time zone so it reads right wherever it is shown
+	now := time.Now().In(database.ClassLocation(store, classId))
+	if !helper.AcceptsSubmission(assignment, now) {
+		slog.InfoContext(r.Context(), "late submission rejected", slog.Int("assignment", assignmentId))
+		http.Error(w, "La fecha de entrega ya pasó", http.StatusForbidden)
+		return
 	}
+	lateStatus := helper.GetLateStatus(assignment, now)
 
-	// Delete old files not in keep[]
-	for _, oldUrl := range submissionModel.Content {
-		if _, ok := keepSet[oldUrl]; !ok {
-			if err := storage.DeleteFile(r.Context(), oldUrl); err != nil {
-				fmt.Printf("⚠️ failed to delete old file %s: %v\n", oldUrl, err)
-			} else {
-				fmt.Printf("🗑 deleted old file %s\n", oldUrl)
-			}
-		}
+	// 2. Load submission, or start a new one on the first turn in
+	previous, err := database.GetSubmission(store, classId, assignmentId, username)
+	if err != nil || previous == nil {
+		slog.DebugContext(r.Context(), "no submission yet, creating it on save")
+		previous = &models.Submission{Username: username}
 	}
 
-	// Upload new files
+	// 3. Upload new files, nothing is deleted until the submission is saved
+	var uploaded []string
 	for _, f := range uploads {
-		fmt.Printf("⬆️ Uploading file: %s\n", f.Filename)
 		file, err := f.Open()
 		if err != nil {
-			fmt.Printf("❌ Failed to open uploaded file %s: %v\n", f.Filename, err)
+			slog.ErrorContext(r.Context(), "failed to open uploaded file", slog.String("file", f.Filename), telemetry.Err(err))
 			http.Error(w, "Failed to open uploaded file", http.StatusInternalServerError)
 			return
 		}
 
 		safeName := helper.NormalizeFilename(f.Filename)
-		key := fmt.Sprintf("submissions/%d/%d/%s", classId, assignmentIdInt, safeName)
-
-		// delete old version if it exists
-		_ = storage.DeleteFile(r.Context(), key)
+		key := fmt.Sprintf("submissions/%d/%d/%s/%s", classId, assignmentId, username, safeName)
 
 		fileURL, err := storage.UploadFile(r.Context(), key, file)
+		_ = file.Close()
 		if err != nil {
-			fmt.Printf("❌ Failed to upload file %s: %v\n", f.Filename, err)
+			slog.ErrorContext(r.Context(), "failed to upload file", slog.String("file", f.Filename), telemetry.Err(err))
 			http.Error(w, "Failed to upload file", http.StatusInternalServerError)
 			return
 		}
-		_ = file.Close()
 
-		fmt.Printf("✅ Uploaded file to %s\n", fileURL)
-		newContent = append(newContent, fileURL)
+		slog.DebugContext(r.Context(), "uploaded file", slog.String("url", fileURL))
+		uploaded = append(uploaded, fileURL)
 	}
 
-	// 3. Update fields
-	submissionModel.Description = description
-	submissionModel.Content = newContent
-	fmt.Printf("📝 Updated submission model: %+v\n", submissionModel)
+	// 4. Save the fields in one go, the server clock decides when it was turned in.
+	// Only files already in the submission can be kept, keep[] can't add other URLs.
+	var dropped []string
+	submissionModel, err := database.UpdateSubmission(store, classId, assignmentId, username, func(s *models.Submission) error {
+		newContent := []string{}
+		for _, k := range keep {
+			if slices.Contains(s.Content, k) && !slices.Contains(newContent, k) {
+				newContent = append(newContent, k)
+			}
+		}
+		for _, url := range uploaded {
+			if !slices.Contains(newContent, url) {
+				newContent = append(newContent, url)
+			}
+		}
 
-	// 4. Save back
-	key := fmt.Sprintf("%d:%d:%s", classId, assignmentIdInt, username)
-	if err := database.Save(store, database.Buckets["submissions"], key, submissionModel); err != nil {
-		fmt.Printf("❌ Failed to save submission: %v\n", err)
+		dropped = dropped[:0]
+		for _, oldUrl := range s.Content {
+			if !slices.Contains(newContent, oldUrl) {
+				dropped = append(dropped, oldUrl)
+			}
+		}
+
+		s.Description = description
+		s.Content = newContent
+		s.SubmittedAt = now.Format(time.RFC3339)
+		s.Late = lateStatus.Late
+		s.DaysLate = lateStatus.DaysLate
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to save submission", telemetry.Err(err))
+		// Nothing points to the new uploads, unless they replaced a file
+		// that was already attached
+		for _, url := range uploaded {
+			if !slices.Contains(previous.Content, url) {
+				_ = storage.DeleteFile(r.Context(), url)
+			}
+		}
 		http.Error(w, "Failed to save submission", http.StatusInternalServerError)
 		return
 	}
-	fmt.Println("✅ Submission saved successfully")
+
+	// The submission is saved, the files it dropped can go
+	for _, oldUrl := range dropped {
+		if err := storage.DeleteFile(r.Context(), oldUrl); err != nil {
+			slog.WarnContext(r.Context(), "failed to delete old file", slog.String("url", oldUrl), telemetry.Err(err))
+		} else {
+			slog.DebugContext(r.Context(), "deleted old file", slog.String("url", oldUrl))
+		}
+	}
+	telemetry.CountSubmission(submissionModel.Late)
+
+	// 5. Re-render the turned in submission
+	classIdString := strconv.Itoa(classId)
+	submissionDetail.SubmissionDetail(submissionModel, assignment, classIdString, strconv.Itoa(assignmentId), false, true).Render(r.Context(), w)
 }
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"frontend/database"
+	"frontend/internal/authz"
+	"frontend/internal/render"
+	"frontend/internal/telemetry"
+	"frontend/templates/body"
+	"frontend/templates/components/tokens"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func HandleAPITokens(store *database.Store, w http.ResponseWriter, r *http.Request, username string) {
+	list, err := database.ListUserAPITokens(store, username)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list API tokens", telemetry.Err(err))
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+
+	render.RenderWithLayout(w, r, tokens.TokensPanel(list, ""), body.Home)
+}
+
+// HandleAPITokenNew issues a personal API token and shows it once in the panel.
+func HandleAPITokenNew(store *database.Store, w http.ResponseWriter, r *http.Request, username string) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Error(w, "El nombre es obligatorio", http.StatusBadRequest)
+		return
+	}
+
+	scopes := r.Form["scopes"]
+	if len(scopes) == 0 {
+		http.Error(w, "Elige al menos un permiso", http.StatusBadRequest)
+		return
+	}
+	for _, scope := range scopes {
+		if !authz.ValidScope(scope) {
+			http.Error(w, "Invalid scope", http.StatusBadRequest)
+			return
+		}
+	}
+
+	days, err := strconv.Atoi(r.FormValue("expires"))
+	if err != nil || !slices.Contains(tokens.ExpiryDays, days) {
+		http.Error(w, "Invalid expiry", http.StatusBadRequest)
+		return
+	}
+
+	token, err := database.CreateAPIToken(store, username, name, scopes, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to create API token", telemetry.Err(err))
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "API token created", slog.String("name", name))
+
+	list, err := database.ListUserAPITokens(store, username)
+	if err != nil {
+		http.Error(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	tokens.TokensPanel(list, token).Render(r.Context(), w)
+}
+
+func HandleAPITokenRevoke(store *database.Store, w http.ResponseWriter, r *http.Request, username, key string) {
+	if err := database.RevokeAPIToken(store, username, key); err != nil {
+		slog.InfoContext(r.Context(), "failed to revoke API token", telemetry.Err(err))
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	slog.InfoContext(r.Context(), "API token revoked")
+
+	// The row is removed by swapping in nothing
+	w.WriteHeader(http.StatusOK)
+}
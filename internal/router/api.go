@@ -0,0 +1,227 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"frontend/database"
+	"frontend/database/models"
+	"frontend/internal/api"
+	"frontend/internal/authz"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bearerToken is the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// requireToken authenticates API requests by their bearer token: either a
+// personal API token, limited to its scopes, or a token from
+// /api/v1/auth/login. The session cookie is not accepted here, so the API
+// needs no CSRF token.
+func requireToken(store *database.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store := store.WithContext(r.Context())
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			api.WriteError(w, http.StatusUnauthorized, "Missing bearer token")
+			return
+		}
+
+		ctx := r.Context()
+		var username string
+		if database.IsAPIToken(token) {
+			t, err := database.GetAPIToken(store, token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				api.WriteError(w, http.StatusUnauthorized, "Invalid or expired token")
+				return
+			}
+			username = t.Username
+			ctx = context.WithValue(ctx, scopesKey, t.Scopes)
+			telemetry.AddAttrs(ctx, slog.String("token", t.Name))
+		} else {
+			session, err := database.GetSession(store, token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				api.WriteError(w, http.StatusUnauthorized, "Invalid or expired token")
+				return
+			}
+			username = session.Username
+		}
+
+		// Tokens of deactivated users stay in place but stop working
+		user, err := database.Get[models.User](store, database.Buckets["users"], username)
+		if err != nil || user.Disabled {
+			api.WriteError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		telemetry.AddAttrs(ctx, slog.String("user", user.Username))
+		ctx = context.WithValue(ctx, userKey, user)
+		ctx = context.WithValue(ctx, sessionKey, token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type apiCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type apiToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleAPILogin trades a username and password for a bearer token. The
+// token is a session without cookie, listed and revocable like any other.
+func handleAPILogin(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	var body apiCredentials
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+
+	ip := clientIP(r)
+	user, refusal, err := authenticate(r.Context(), store, body.Username, body.Password, ip)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "Internal error")
+		return
+	}
+	if refusal != "" {
+		api.WriteError(w, http.StatusUnauthorized, refusal)
+		return
+	}
+
+	token, err := database.GenerateSession(store, user.Username, r.UserAgent(), ip)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "Failed to create token")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusCreated, apiToken{Token: token, ExpiresAt: time.Now().Add(database.SessionMaxAge)})
+}
+
+// newAPI builds the /api/v1 routes. Errors are always JSON bodies.
+func newAPI(store *database.Store) http.Handler {
+	mux := http.NewServeMux()
+
+	// db binds the store to the request so its transactions join the request's trace
+	db := func(r *http.Request) *database.Store {
+		return store.WithContext(r.Context())
+	}
+
+	// token wraps routes that need a bearer token
+	token := func(h func(w http.ResponseWriter, r *http.Request, user *models.User)) http.Handler {
+		return requireToken(store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h(w, r, currentUser(r))
+		}))
+	}
+	// class wraps routes under a {class} that need action in that class
+	class := func(action authz.Action, h func(w http.ResponseWriter, r *http.Request, user *models.User, class *models.Class)) http.Handler {
+		return token(func(w http.ResponseWriter, r *http.Request, user *models.User) {
+			class, status := loadClass(store, r, action)
+			switch status {
+			case http.StatusOK:
+				h(w, r, user, class)
+			case http.StatusNotFound:
+				api.WriteError(w, status, "Class not found")
+			default:
+				api.WriteError(w, status, "Access denied")
+			}
+		})
+	}
+	// assignment is class for routes that also carry an assignment {id}
+	assignment := func(action authz.Action, h func(w http.ResponseWriter, r *http.Request, user *models.User, classId, assignmentId int)) http.Handler {
+		return class(action, func(w http.ResponseWriter, r *http.Request, user *models.User, class *models.Class) {
+			assignmentId, err := strconv.Atoi(r.PathValue("id"))
+			if err != nil {
+				api.WriteError(w, http.StatusNotFound, "Assignment not found")
+				return
+			}
+			h(w, r, user, class.Id, assignmentId)
+		})
+	}
+
+	mux.HandleFunc("POST /api/v1/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		handleAPILogin(db(r), w, r)
+	})
+	mux.Handle("POST /api/v1/auth/logout", token(func(w http.ResponseWriter, r *http.Request, user *models.User) {
+		// Ends whichever token made the request
+		if token := currentSessionID(r); database.IsAPIToken(token) {
+			_ = database.DeleteAPIToken(db(r), token)
+		} else {
+			_ = database.DeleteSession(db(r), token)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.Handle("GET /api/v1/me", token(func(w http.ResponseWriter, r *http.Request, user *models.User) {
+		api.Me(w, r, user)
+	}))
+
+	mux.Handle("GET /api/v1/classes", token(func(w http.ResponseWriter, r *http.Request, user *models.User) {
+		api.ListClasses(db(r), w, r, user)
+	}))
+	mux.Handle("GET /api/v1/classes/{class}", class(authz.ViewClass, api.GetClass))
+	mux.Handle("GET /api/v1/classes/{class}/grades", class(authz.ViewClass, func(w http.ResponseWriter, r *http.Request, user *models.User, class *models.Class) {
+		// Whoever may not see the gradebook only gets their own row
+		all := authz.Can(user, class, authz.ViewGradebook) && scopeAllows(r, authz.ViewGradebook)
+		api.ListGrades(db(r), w, r, user, class, all)
+	}))
+	mux.Handle("GET /api/v1/classes/{class}/assignments", class(authz.ViewClass, func(w http.ResponseWriter, r *http.Request, user *models.User, class *models.Class) {
+		api.ListAssignments(db(r), w, r, class.Id)
+	}))
+	mux.Handle("POST /api/v1/classes/{class}/assignments", class(authz.ManageAssignments, func(w http.ResponseWriter, r *http.Request, user *models.User, class *models.Class) {
+		api.CreateAssignment(db(r), w, r, class.Id)
+	}))
+	mux.Handle("GET /api/v1/classes/{class}/assignments/{id}", assignment(authz.ViewClass, func(w http.ResponseWriter, r *http.Request, user *models.User, classId, assignmentId int) {
+		api.GetAssignment(db(r), w, r, classId, assignmentId)
+	}))
+	mux.Handle("GET /api/v1/classes/{class}/assignments/{id}/submissions", assignment(authz.ViewSubmissions, func(w http.ResponseWriter, r *http.Request, user *models.User, classId, assignmentId int) {
+		api.ListSubmissions(db(r), w, r, classId, assignmentId)
+	}))
+	mux.Handle("GET /api/v1/classes/{class}/assignments/{id}/submissions/{user}", assignment(authz.ViewClass, func(w http.ResponseWriter, r *http.Request, user *models.User, classId, assignmentId int) {
+		student := r.PathValue("user")
+
+		// Students may read their own submission, graders everyone's
+		action := authz.ViewSubmissions
+		if student == user.Username {
+			action = authz.ViewOwnSubmission
+		}
+		if _, status := loadClass(store, r, action); status != http.StatusOK {
+			api.WriteError(w, http.StatusForbidden, "Access denied")
+			return
+		}
+
+		api.GetSubmission(db(r), w, r, classId, assignmentId, student)
+	}))
+	mux.Handle("PUT /api/v1/classes/{class}/assignments/{id}/submissions/{user}", assignment(authz.SubmitWork, func(w http.ResponseWriter, r *http.Request, user *models.User, classId, assignmentId int) {
+		// Work is only ever turned in by its author
+		if r.PathValue("user") != user.Username {
+			api.WriteError(w, http.StatusForbidden, "Access denied")
+			return
+		}
+		api.Submit(db(r), w, r, classId, assignmentId, user.Username)
+	}))
+	mux.Handle("PUT /api/v1/classes/{class}/assignments/{id}/submissions/{user}/grade", assignment(authz.GradeSubmissions, func(w http.ResponseWriter, r *http.Request, user *models.User, classId, assignmentId int) {
+		api.GradeSubmission(db(r), w, r, classId, assignmentId, r.PathValue("user"), user.Username)
+	}))
+
+	// Anything else under /api/ answers with a JSON error too
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		api.WriteError(w, http.StatusNotFound, "No such endpoint")
+	})
+
+	return mux
+}
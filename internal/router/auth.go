@@ -0,0 +1,97 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"frontend/auth"
+	"frontend/database"
+	"frontend/database/models"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+)
+
+// authenticate checks a username and password against the login limits.
+// A non empty refusal is the message to show instead of logging in; it never
+// says whether the user exists.
+func authenticate(ctx context.Context, store *database.Store, username, password, ip string) (*models.User, string, error) {
+	until, err := database.LoginLockedUntil(store, username, ip, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+	if !until.IsZero() {
+		minutes := int(math.Ceil(time.Until(until).Minutes()))
+		return nil, fmt.Sprintf("Demasiados intentos fallidos. Intenta de nuevo en %d min.", minutes), nil
+	}
+
+	// Same answer for unknown users and wrong passwords, so usernames can't be probed
+	user, err := database.Get[models.User](store, database.Buckets["users"], username)
+	if err != nil {
+		auth.FakeCheckPassword(password)
+		return nil, loginFailed(ctx, store, username, ip), nil
+	}
+
+	if !auth.CheckPassword(user.PasswordHashed, password) {
+		return nil, loginFailed(ctx, store, username, ip), nil
+	}
+
+	if err := database.RecordLoginSuccess(store, username); err != nil {
+		slog.WarnContext(ctx, "failed to clear login failures", slog.String("user", username), telemetry.Err(err))
+	}
+
+	if user.Disabled {
+		return nil, "Usuario desactivado", nil
+	}
+
+	// Hashes from before the current bcrypt cost are upgraded while we have the password
+	if auth.NeedsRehash(user.PasswordHashed) {
+		if err := database.SetPassword(store, username, password); err != nil {
+			slog.WarnContext(ctx, "failed to upgrade password hash", slog.String("user", username), telemetry.Err(err))
+		}
+	}
+
+	return user, "", nil
+}
+
+// handleLogin checks the credentials and starts a new session
+func handleLogin(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	ip := clientIP(r)
+	user, refusal, err := authenticate(r.Context(), store, r.FormValue("username"), r.FormValue("password"), ip)
+	if err != nil {
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if refusal != "" {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(refusal))
+		return
+	}
+
+	// Never reuse a session id the browser already had: logging in always issues a new one
+	if old, err := r.Cookie("session_id"); err == nil {
+		_ = database.DeleteSession(store, old.Value)
+	}
+
+	sessionID, err := database.GenerateSession(store, user.Username, r.UserAgent(), ip)
+	if err != nil {
+		http.Error(w, "Error creando sesión", http.StatusInternalServerError)
+		return
+	}
+
+	setSessionCookie(w, r, sessionID, int(database.SessionMaxAge.Seconds()))
+
+	w.Header().Set("HX-Redirect", "/")
+}
+
+// handleLogout ends the session of the request
+func handleLogout(store *database.Store, w http.ResponseWriter, r *http.Request) {
+	_ = database.DeleteSession(store, currentSessionID(r))
+	// Clear cookie
+	setSessionCookie(w, r, "", -1)
+
+	// HX-Redirect header makes HTMX go there
+	w.Header().Set("HX-Redirect", "/login")
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,36 +1,128 @@
 package router
 
 import (
+	"context"
 	"frontend/database"
-	"frontend/database/models"
-	"strconv"
+	"frontend/internal/config"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
 )
 
-func isClassValid(store *database.Store, username, classId string) bool {
-	classes, err := database.ListClassesForUser(store, username)
-	if err != nil {
-		return false
-	}
-	classIds, err := strconv.Atoi(classId)
-	if err != nil {
-		return false
+// client is what is known about the other end of a request
+type client struct {
+	ip     string
+	secure bool // cookies are marked Secure
+}
+
+// identifyClient works out the client address of each request and whether
+// its cookies are marked Secure, given a config.SecureCookies mode: always,
+// never, or in auto mode when it was served over HTTPS. X-Forwarded-For and
+// X-Forwarded-Proto are only believed when the request comes from one of
+// proxies, anyone else could make them up.
+func identifyClient(proxies []netip.Prefix, cookies string, next http.Handler) http.Handler {
+	trusted := func(ip string) bool {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return false
+		}
+		addr = addr.Unmap()
+		return slices.ContainsFunc(proxies, func(p netip.Prefix) bool { return p.Contains(addr) })
 	}
 
-	for _, class := range classes {
-		if class.Id == classIds {
-			return true
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := client{ip: r.RemoteAddr}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			c.ip = host
 		}
+		https := r.TLS != nil
+
+		if trusted(c.ip) {
+			// Each proxy appends the address it got the request from, the
+			// client is the last one that isn't a proxy of ours
+			hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				hop := strings.TrimSpace(hops[i])
+				if hop == "" {
+					continue
+				}
+				if _, err := netip.ParseAddr(hop); err != nil {
+					break
+				}
+				c.ip = hop
+				if !trusted(hop) {
+					break
+				}
+			}
+			https = https || r.Header.Get("X-Forwarded-Proto") == "https"
+		}
+
+		c.secure = cookies == config.CookiesAlways || cookies == config.CookiesAuto && https
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientKey, c)))
+	})
+}
+
+// clientIP is the address the request came from, without the port, set by identifyClient
+func clientIP(r *http.Request) string {
+	c, _ := r.Context().Value(clientKey).(client)
+	return c.ip
+}
+
+// loginFailed counts a failed login and returns the refusal, without saying which part was wrong
+func loginFailed(ctx context.Context, store *database.Store, username, ip string) string {
+	if err := database.RecordLoginFailure(store, username, ip, time.Now()); err != nil {
+		slog.WarnContext(ctx, "failed to record login failure", telemetry.Err(err))
 	}
-	return false
+	return "Usuario o contraseña incorrectos"
 }
 
-func isProfessor(store *database.Store, username string) (bool, error) {
-	user, err := database.Get[models.User](store, []byte("Users"), username)
-	if err != nil {
-		return false, err
+// secureRequest reports whether cookies of r are marked Secure, set by identifyClient
+func secureRequest(r *http.Request) bool {
+	c, _ := r.Context().Value(clientKey).(client)
+	return c.secure
+}
+
+// publicURL is the address users reach the site at, for links handed out:
+// BASE_URL, else the listen address with the scheme secureRequest implies.
+// The Host header is never used, a client could point links anywhere.
+func publicURL(cfg *config.Config, r *http.Request) string {
+	if cfg.BaseURL != "" {
+		return cfg.BaseURL
+	}
+	scheme := "http"
+	if secureRequest(r) {
+		scheme = "https"
 	}
-	if user.Role == "professor" {
-		return true, nil
+	host, port, _ := net.SplitHostPort(cfg.Addr)
+	if host == "" {
+		host = "localhost"
 	}
-	return false, nil
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// setSessionCookie writes the session cookie, marked Secure as configured
+func setSessionCookie(w http.ResponseWriter, r *http.Request, sessionID string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_id",
+		Value:    sessionID,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// limitBodies caps the size of every request body at max bytes. Handlers
+// see a *http.MaxBytesError when an upload goes over.
+func limitBodies(max int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		next.ServeHTTP(w, r)
+	})
 }
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"crypto/subtle"
+	"frontend/database"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The gauges are registered once per process, a second router would panic on
+// the duplicate; they read the store of the last router built
+var (
+	gaugeStore     atomic.Pointer[database.Store]
+	registerGauges sync.Once
+)
+
+// newMetrics serves the Prometheus metrics to operators: scrapers send
+// token as a bearer token, admins can also open the page with their session.
+// An empty token leaves the page to admins only.
+func newMetrics(store *database.Store, token string) http.Handler {
+	gaugeStore.Store(store)
+	registerGauges.Do(func() {
+		telemetry.Gauge("bbolt_db_size_bytes", "Size of the database file.", func() float64 {
+			size, err := gaugeStore.Load().Size()
+			if err != nil {
+				slog.Warn("failed to read database size", telemetry.Err(err))
+			}
+			return float64(size)
+		})
+		telemetry.Gauge("sessions_active", "Sessions that haven't expired.", func() float64 {
+			count, err := database.CountActiveSessions(gaugeStore.Load(), time.Now())
+			if err != nil {
+				slog.Warn("failed to count sessions", telemetry.Err(err))
+			}
+			return float64(count)
+		})
+	})
+
+	metrics := telemetry.MetricsHandler()
+	admins := requireSession(store, requireAdmin(metrics))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := bearerToken(r)
+		if !ok {
+			admins.ServeHTTP(w, r)
+			return
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			slog.WarnContext(r.Context(), "metrics scrape with an invalid token")
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+		metrics.ServeHTTP(w, r)
+	})
+}
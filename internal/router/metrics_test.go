@@ -0,0 +1,39 @@
+package router
+
+import (
+	"frontend/database"
+	"frontend/internal/config"
+	"frontend/storage"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Building a second router in the same process must not register the
+// gauges again
+func TestNewTwice(t *testing.T) {
+	for i := range 2 {
+		dir := t.TempDir()
+		store, _, err := database.Open(filepath.Join(dir, "school.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(store.Close)
+		files, err := storage.InitLocal(filepath.Join(dir, "files"), "/files/")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h := New(store, files, &config.Config{MetricsToken: "secret", SecureCookies: config.CookiesAuto, MaxUploadBytes: 1 << 20})
+
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		r.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "bbolt_db_size_bytes") {
+			t.Fatalf("router %d: /metrics answered %d without the database size", i, w.Code)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"frontend/database"
+	"frontend/database/models"
+	"frontend/internal/authz"
+	"frontend/internal/csrf"
+	"frontend/internal/telemetry"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type ctxKey int
+
+const (
+	userKey ctxKey = iota
+	sessionKey
+	classKey
+	scopesKey
+	clientKey
+)
+
+// currentUser is the logged in user, set by requireSession
+func currentUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(userKey).(*models.User)
+	return user
+}
+
+// currentSessionID is the raw session id of the request, set by requireSession
+func currentSessionID(r *http.Request) string {
+	id, _ := r.Context().Value(sessionKey).(string)
+	return id
+}
+
+// currentClass is the class named by {class}, set by requireClass
+func currentClass(r *http.Request) *models.Class {
+	class, _ := r.Context().Value(classKey).(*models.Class)
+	return class
+}
+
+// scopeAllows reports whether the credential of the request covers action.
+// Only personal API tokens carry scopes; sessions may do whatever the user may.
+func scopeAllows(r *http.Request, action authz.Action) bool {
+	scopes, ok := r.Context().Value(scopesKey).([]string)
+	return !ok || authz.ScopeAllows(scopes, action)
+}
+
+// isProfessor reports whether the user teaches the current class
+func isProfessor(r *http.Request) bool {
+	return authz.ClassRole(currentUser(r), currentClass(r)) == models.RoleProfessor
+}
+
+// pathInt reads a numeric path value, answering 404 when it isn't one
+func pathInt(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	v, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		http.NotFound(w, r)
+		return 0, false
+	}
+	return v, true
+}
+
+// statusRecorder remembers the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// observe gives every request an id, a span, one log line with its status
+// and duration, and counts it in the request metrics. The id comes from
+// X-Request-ID when the proxy sets one.
+func observe(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get("X-Request-ID")
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := telemetry.Start(ctx, r.Method,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("client.address", clientIP(r)),
+		)
+		ctx = telemetry.WithRequest(ctx, slog.String("request_id", requestID))
+		span.SetAttributes(attribute.String("request_id", requestID))
+		r = r.WithContext(ctx)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// The mux fills in the matched pattern on the way in
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(attribute.String("http.route", r.Pattern))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+		span.End()
+		telemetry.ObserveRequest(r.Method, r.Pattern, rec.status, time.Since(start))
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", r.Pattern),
+			slog.Int("status", rec.status),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+// validRequestID accepts ids from upstream only when they are short and plain
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// recoverPanics turns a panicking handler into a 500 instead of a dropped connection
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				slog.ErrorContext(r.Context(), "panic serving request",
+					slog.Any("panic", err),
+					slog.String("stack", string(debug.Stack())),
+				)
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireSession resolves the session cookie into a user, checks the CSRF
+// token of state-changing requests and sends everyone else to /login.
+func requireSession(store *database.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store := store.WithContext(r.Context())
+		cookie, err := r.Cookie("session_id")
+		if err != nil {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		sessionID := cookie.Value
+
+		session, err := database.GetSession(store, sessionID)
+		if err != nil {
+			setSessionCookie(w, r, "", -1)
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+
+		// Every state-changing request must carry the session's CSRF token
+		if !csrf.Safe(r.Method) && !csrf.Valid(r, session.CSRFToken) {
+			slog.WarnContext(r.Context(), "CSRF token missing or invalid", slog.String("user", session.Username))
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+
+		// Deactivated accounts lose their sessions on the next request
+		user, err := database.Get[models.User](store, database.Buckets["users"], session.Username)
+		if err != nil || user.Disabled {
+			_ = database.DeleteSession(store, sessionID)
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+
+		telemetry.AddAttrs(r.Context(), slog.String("user", user.Username))
+
+		ctx := csrf.WithToken(r.Context(), session.CSRFToken)
+		ctx = context.WithValue(ctx, userKey, user)
+		ctx = context.WithValue(ctx, sessionKey, sessionID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireAdmin lets only admins through. It runs after requireSession.
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authz.Can(currentUser(r), nil, authz.Administer) {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// loadClass resolves the {class} of the route for the current user. It
+// answers 404 to non members, so class ids can't be probed, and 403 when the
+// user is a member but may not perform action.
+func loadClass(store *database.Store, r *http.Request, action authz.Action) (*models.Class, int) {
+	classId, err := strconv.Atoi(r.PathValue("class"))
+	if err != nil {
+		return nil, http.StatusNotFound
+	}
+
+	user := currentUser(r)
+	class, err := database.GetClass(store.WithContext(r.Context()), classId)
+	if err != nil || !authz.Can(user, class, authz.ViewClass) {
+		return nil, http.StatusNotFound
+	}
+	if !authz.Can(user, class, action) || !scopeAllows(r, action) {
+		slog.WarnContext(r.Context(), "access denied", slog.String("action", string(action)), slog.Int("class", class.Id))
+		return nil, http.StatusForbidden
+	}
+	telemetry.AddAttrs(r.Context(), slog.Int("class", class.Id))
+	return class, http.StatusOK
+}
+
+// requireClass puts the class of the route in the context once the user may
+// perform action in it. It runs after requireSession.
+func requireClass(store *database.Store, action authz.Action, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class, status := loadClass(store, r, action)
+		switch status {
+		case http.StatusOK:
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), classKey, class)))
+		case http.StatusNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "Access denied", status)
+		}
+	})
+}
@@ -1,214 +1,249 @@
 package router
 
 import (
-	"fmt"
-	"frontend/auth"
 	"frontend/database"
 	"frontend/database/models"
-	"frontend/helper"
+	"frontend/internal/authz"
+	"frontend/internal/config"
 	"frontend/internal/handlers"
 	"frontend/internal/render"
+	"frontend/internal/telemetry"
 	"frontend/storage"
 	"frontend/templates/body"
 	"frontend/templates/components/home"
-	"log"
+	"log/slog"
 	"net/http"
-	"strconv"
-	"strings"
 )
 
-func Router(store *database.Store, storage *storage.B2Storage, w http.ResponseWriter, r *http.Request) {
-	path := strings.Trim(r.URL.Path, "/")
-	parts := strings.Split(path, "/")
-
-	var username string
-	if parts[0] != "login" { // protect everything except /login
-		cookie, err := r.Cookie("session_id")
-		if err != nil {
-			http.Redirect(w, r, "/login", http.StatusFound)
-			return
-		}
-
-		username, err = database.GetUserFromSession(store, cookie.Value)
-		if err != nil {
-			http.Redirect(w, r, "/login", http.StatusFound)
-			return
-		}
+// New builds the application handler: every route of the site with its
+// middleware, the JSON API, the metrics page, static assets and locally
+// stored files.
+func New(store *database.Store, files storage.Storage, cfg *config.Config) http.Handler {
+	local := files
+	mux := http.NewServeMux()
+	files = storage.Instrument(files)
+
+	// db binds the store to the request so its transactions join the request's trace
+	db := func(r *http.Request) *database.Store {
+		return store.WithContext(r.Context())
 	}
-
-	switch {
-	case parts[0] == "login":
-		switch r.Method {
-		case http.MethodGet:
-			render.RenderWithLayout(w, r, body.Auth())
-
-		case http.MethodPost:
-			username := r.FormValue("username")
-			password := r.FormValue("password")
-
-			user, err := database.Get[models.User](store, database.Buckets["users"], username)
-			if err != nil {
-				w.WriteHeader(http.StatusOK)
-				w.Write([]byte("Usuario no encontrado"))
-				return
-			}
-
-			if !auth.CheckPassword(user.PasswordHashed, password) {
-				w.WriteHeader(http.StatusOK)
-				w.Write([]byte("Contraseña incorrecta"))
-				return
-			}
-
-			sessionID, err := database.GenerateSession(store, username)
-			if err != nil {
-				http.Error(w, "Error creando sesión", http.StatusInternalServerError)
+	// session wraps routes that need a logged in user
+	session := func(h http.HandlerFunc) http.Handler {
+		return requireSession(store, h)
+	}
+	// admin wraps routes of the admin console
+	admin := func(h http.HandlerFunc) http.Handler {
+		return requireSession(store, requireAdmin(h))
+	}
+	// class wraps routes under /{class}/ that need action in that class
+	class := func(action authz.Action, h http.HandlerFunc) http.Handler {
+		return requireSession(store, requireClass(store, action, h))
+	}
+	// assignment is class for routes that also carry an assignment {id}
+	assignment := func(action authz.Action, h func(w http.ResponseWriter, r *http.Request, classId, assignmentId int)) http.Handler {
+		return class(action, func(w http.ResponseWriter, r *http.Request) {
+			assignmentId, ok := pathInt(w, r, "id")
+			if !ok {
 				return
 			}
-
-			http.SetCookie(w, &http.Cookie{
-				Name:     "session_id",
-				Value:    sessionID,
-				Path:     "/",
-				HttpOnly: true,
-				Secure:   false, // set true in production with HTTPS
-				SameSite: http.SameSiteLaxMode,
-			})
-
-			w.Header().Set("HX-Redirect", "/")
-			return
-
-		default:
-			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
-			return
-		}
-
-	case parts[0] == "logout":
-		cookie, err := r.Cookie("session_id")
-		if err == nil {
-			err = database.DeleteSession(store, cookie.Value)
-		}
-		// Clear cookie
-		http.SetCookie(w, &http.Cookie{
-			Name:     "session_id",
-			Value:    "",
-			Path:     "/",
-			MaxAge:   -1,
-			HttpOnly: true,
-			Secure:   false,
-			SameSite: http.SameSiteLaxMode,
+			h(w, r, currentClass(r).Id, assignmentId)
 		})
+	}
 
-		// HX-Redirect header makes HTMX go there
-		w.Header().Set("HX-Redirect", "/login")
-		w.WriteHeader(http.StatusOK)
-		return
-
-	case parts[0] == "":
-		classes, err := database.ListClassesForUser(store, username)
+	// Public
+	mux.HandleFunc("GET /login", func(w http.ResponseWriter, r *http.Request) {
+		render.RenderWithLayout(w, r, body.Auth())
+	})
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		handleLogin(db(r), w, r)
+	})
+	mux.HandleFunc("GET /login/reset/{token}", func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandlePasswordResetPage(db(r), w, r, r.PathValue("token"))
+	})
+	mux.HandleFunc("POST /login/reset/{token}", func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandlePasswordReset(db(r), w, r, r.PathValue("token"))
+	})
+
+	// Account
+	mux.Handle("POST /logout", session(func(w http.ResponseWriter, r *http.Request) {
+		handleLogout(db(r), w, r)
+	}))
+	mux.Handle("GET /{$}", session(func(w http.ResponseWriter, r *http.Request) {
+		user := currentUser(r)
+		classes, err := database.ListClassesForUser(db(r), user.Username)
 		if err != nil {
-			log.Printf("fallback: user %s classes not loaded: %v", username, err)
+			slog.WarnContext(r.Context(), "classes not loaded, showing none", telemetry.Err(err))
 			classes = []*models.Class{}
 		}
 
-		professor, err := isProfessor(store, username)
-		if err != nil {
-			http.Error(w, "Internal error", http.StatusInternalServerError)
-			return
+		// The buttons on each class card depend on the role in that class
+		professorOf := map[int]bool{}
+		for _, class := range classes {
+			professorOf[class.Id] = authz.ClassRole(user, class) == models.RoleProfessor
 		}
 
-		render.RenderWithLayout(w, r, home.Home(classes, professor), body.Home)
-		return
-
-	case isClassValid(store, username, parts[0]):
-		fmt.Println("🔎 Router parts:", parts)
-
-		if len(parts) > 1 {
-			classId, err := strconv.Atoi(parts[0])
-			if err != nil {
-				http.Error(w, "Error with the class id", http.StatusInternalServerError)
-			}
-			switch parts[1] {
-			case "asignaciones":
-
-				helper.PrintArray(parts)
-
-				professor, err := isProfessor(store, username)
-				if err != nil {
-					http.Error(w, "Internal error", http.StatusInternalServerError)
-					return
-				}
-
-				if len(parts) == 3 && parts[2] == "new" {
-					fmt.Println("📌 Routed to NewAssignment (professor)")
-					handlers.HandleAssignmentNew(store, storage, w, r, classId, professor)
-					return
-				}
-
-				if len(parts) == 3 && parts[2] == "delete" {
-					fmt.Println("📌 Routed to DeleteAssignment (professor)")
-					handlers.HandleAssignmentDelete(store, storage, w, r, classId, professor)
-					return
-				}
-
-				if len(parts) == 4 && parts[3] == "update" {
-					fmt.Println("📌 Routed to UpdateAssignment (professor)")
-					handlers.HandleAssignmentUpdate(store, storage, w, r, classId, parts[2], professor)
-					return
-				}
-
-				if len(parts) == 4 && parts[3] == "submissions" {
-					fmt.Println("📌 Routed to HandleAssignmentSubmissions")
-					handlers.HandleAssignmentSubmissions(store, w, r, professor)
-					return
-				}
-
-				if len(parts) == 4 && parts[3] == "details" {
-					fmt.Println("📌 Routed to HandleAssignmentDetail")
-					handlers.HandleAssignmentDetail(store, w, r, classId, professor)
-					return
-				}
-
-				if len(parts) == 5 && parts[3] == "submission" && parts[4] == "update" {
-					fmt.Println("📌 Routed to HandleAssignmentSubmissionsUpdate")
-					handlers.HandleSubmissionUpdate(store, storage, w, r, classId, parts[2], username, professor)
-					return
-				}
-
-				if len(parts) == 5 && parts[3] == "submission" {
-					fmt.Println("📌 Routed to HandleAssignmentSubmissions")
-					handlers.HandleAssignmentSubmission(store, w, r, username, professor)
-					return
-				}
-
-				if len(parts) == 6 && parts[3] == "submission" && parts[5] == "grade" {
-					fmt.Println("📌 Routed to HandleAssignmentGrade")
-					handlers.HandleSubmissionGrade(store, w, r, classId, parts[4], professor)
-					return
-				}
-
-				fmt.Println("📌 Routed to HandleAssignmentDefault")
-				handlers.HandleAssignmentDefault(store, w, r, classId, professor, username)
-				return
-
-			case "entregas":
-				classId, _ := strconv.Atoi(parts[0])
-
-				professor, err := isProfessor(store, username)
-				if err != nil {
-					http.Error(w, "Internal error", http.StatusInternalServerError)
-					return
-				}
+		render.RenderWithLayout(w, r, home.Home(classes, professorOf, authz.Can(user, nil, authz.Administer)), body.Home)
+	}))
+	mux.Handle("GET /cuenta/contrasena", session(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandlePasswordPage(w, r)
+	}))
+	mux.Handle("POST /cuenta/contrasena", session(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandlePasswordChange(db(r), w, r, currentUser(r).Username, currentSessionID(r))
+	}))
+	mux.Handle("GET /cuenta/tokens", session(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAPITokens(db(r), w, r, currentUser(r).Username)
+	}))
+	mux.Handle("POST /cuenta/tokens/new", session(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAPITokenNew(db(r), w, r, currentUser(r).Username)
+	}))
+	mux.Handle("POST /cuenta/tokens/{key}/revoke", session(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAPITokenRevoke(db(r), w, r, currentUser(r).Username, r.PathValue("key"))
+	}))
+	mux.Handle("GET /sesiones", session(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleSessions(db(r), w, r, currentUser(r).Username, currentSessionID(r))
+	}))
+	mux.Handle("POST /sesiones/revoke-others", session(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleSessionRevokeOthers(db(r), w, r, currentUser(r).Username, currentSessionID(r))
+	}))
+	mux.Handle("POST /sesiones/{key}/revoke", session(func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+		if key == database.SessionKey(currentSessionID(r)) {
+			// Revoking the session in use is the same as logging out
+			handleLogout(db(r), w, r)
+			return
+		}
+		handlers.HandleSessionRevoke(db(r), w, r, currentUser(r).Username, currentSessionID(r), key)
+	}))
 
-				fmt.Printf("📌 Routed to HandleSubmissionDefault")
-				handlers.HandleSubmissionDefault(store, w, r, classId, professor, username)
-				return
-			}
+	// Admin console
+	users := func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminUsers(db(r), w, r, currentUser(r).Username)
+	}
+	mux.Handle("GET /admin", admin(users))
+	mux.Handle("GET /admin/usuarios", admin(users))
+	mux.Handle("POST /admin/usuarios/new", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminUserNew(db(r), w, r, currentUser(r).Username)
+	}))
+	mux.Handle("POST /admin/usuarios/{user}/update", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminUserUpdate(db(r), w, r, currentUser(r).Username, r.PathValue("user"))
+	}))
+	mux.Handle("POST /admin/usuarios/{user}/deactivate", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminUserDisable(db(r), w, r, currentUser(r).Username, r.PathValue("user"), true)
+	}))
+	mux.Handle("POST /admin/usuarios/{user}/activate", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminUserDisable(db(r), w, r, currentUser(r).Username, r.PathValue("user"), false)
+	}))
+	mux.Handle("POST /admin/usuarios/{user}/reset", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminUserReset(db(r), w, r, currentUser(r).Username, r.PathValue("user"), publicURL(cfg, r))
+	}))
+	mux.Handle("GET /admin/clases", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminClasses(db(r), w, r)
+	}))
+	mux.Handle("POST /admin/clases/new", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminClassNew(db(r), w, r)
+	}))
+	mux.Handle("POST /admin/clases/{class}/enroll", admin(func(w http.ResponseWriter, r *http.Request) {
+		if classId, ok := pathInt(w, r, "class"); ok {
+			handlers.HandleAdminClassMember(db(r), w, r, classId, true)
+		}
+	}))
+	mux.Handle("POST /admin/clases/{class}/remove", admin(func(w http.ResponseWriter, r *http.Request) {
+		if classId, ok := pathInt(w, r, "class"); ok {
+			handlers.HandleAdminClassMember(db(r), w, r, classId, false)
+		}
+	}))
+	mux.Handle("POST /admin/clases/{class}/role", admin(func(w http.ResponseWriter, r *http.Request) {
+		if classId, ok := pathInt(w, r, "class"); ok {
+			handlers.HandleAdminClassRole(db(r), w, r, classId)
+		}
+	}))
+	mux.Handle("GET /admin/importar", admin(handlers.HandleAdminImport))
+	mux.Handle("POST /admin/importar", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminImportRun(db(r), w, r)
+	}))
+	mux.Handle("GET /admin/bloqueos", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminLockouts(db(r), w, r)
+	}))
+	mux.Handle("POST /admin/bloqueos/unlock", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminUnlock(db(r), w, r)
+	}))
+	mux.Handle("GET /admin/copias", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminBackups(w, r, cfg.Backup.Dir)
+	}))
+	mux.Handle("GET /admin/copias/descargar", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminBackupDownload(db(r), w, r)
+	}))
+	mux.Handle("GET /admin/copias/{name}", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminBackupFile(w, r, cfg.Backup.Dir, r.PathValue("name"))
+	}))
+	mux.Handle("GET /admin/materias", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminSubjects(db(r), w, r)
+	}))
+	mux.Handle("POST /admin/materias/new", admin(func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAdminSubjectNew(db(r), w, r)
+	}))
+
+	// Class pages
+	mux.Handle("GET /{class}/asignaciones", class(authz.ViewClass, func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAssignmentDefault(db(r), w, r, currentClass(r).Id, isProfessor(r), currentUser(r).Username)
+	}))
+	mux.Handle("POST /{class}/asignaciones/new", class(authz.ManageAssignments, func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleAssignmentNew(db(r), files, w, r, currentClass(r).Id)
+	}))
+	mux.Handle("DELETE /{class}/asignaciones/{id}", assignment(authz.ManageAssignments, func(w http.ResponseWriter, r *http.Request, classId, assignmentId int) {
+		handlers.HandleAssignmentDelete(db(r), files, w, r, classId, assignmentId)
+	}))
+	mux.Handle("POST /{class}/asignaciones/{id}/update", assignment(authz.ManageAssignments, func(w http.ResponseWriter, r *http.Request, classId, assignmentId int) {
+		handlers.HandleAssignmentUpdate(db(r), files, w, r, classId, assignmentId)
+	}))
+	mux.Handle("GET /{class}/asignaciones/{id}/details", assignment(authz.ManageAssignments, func(w http.ResponseWriter, r *http.Request, classId, assignmentId int) {
+		handlers.HandleAssignmentDetail(db(r), w, r, classId, assignmentId)
+	}))
+	mux.Handle("GET /{class}/asignaciones/{id}/submissions", assignment(authz.ViewSubmissions, func(w http.ResponseWriter, r *http.Request, classId, assignmentId int) {
+		handlers.HandleAssignmentSubmissions(db(r), w, r, classId, assignmentId)
+	}))
+	mux.Handle("POST /{class}/asignaciones/{id}/submission/update", assignment(authz.SubmitWork, func(w http.ResponseWriter, r *http.Request, classId, assignmentId int) {
+		handlers.HandleSubmissionUpdate(db(r), files, w, r, classId, assignmentId, currentUser(r).Username)
+	}))
+	mux.Handle("GET /{class}/asignaciones/{id}/submission/{user}", assignment(authz.ViewClass, func(w http.ResponseWriter, r *http.Request, classId, assignmentId int) {
+		user := currentUser(r)
+		student := r.PathValue("user")
+
+		// Students may open their own submission, graders everyone's
+		action := authz.ViewSubmissions
+		if student == user.Username {
+			action = authz.ViewOwnSubmission
+		}
+		if !authz.Can(user, currentClass(r), action) {
+			http.Error(w, "Access denied", http.StatusForbidden)
+			return
 		}
-		http.NotFound(w, r)
-		return
 
-	default:
-		http.NotFound(w, r)
-		return
+		handlers.HandleAssignmentSubmission(db(r), w, r, classId, assignmentId, student, user.Username, isProfessor(r))
+	}))
+	mux.Handle("POST /{class}/asignaciones/{id}/submission/{user}/grade", assignment(authz.GradeSubmissions, func(w http.ResponseWriter, r *http.Request, classId, assignmentId int) {
+		handlers.HandleSubmissionGrade(db(r), files, w, r, classId, assignmentId, r.PathValue("user"), currentUser(r).Username)
+	}))
+	mux.Handle("GET /{class}/entregas", class(authz.ViewSubmissions, func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleSubmissionDefault(db(r), w, r, currentClass(r).Id, isProfessor(r), currentUser(r).Username)
+	}))
+	mux.Handle("GET /{class}/calificaciones", class(authz.ViewGradebook, func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleGradebook(db(r), w, r, currentClass(r).Id)
+	}))
+	mux.Handle("GET /{class}/calificaciones/export", class(authz.ViewGradebook, func(w http.ResponseWriter, r *http.Request) {
+		handlers.HandleGradebookExport(db(r), w, r, currentClass(r).Id)
+	}))
+
+	// Assets, the API and metrics live outside the app mux: "/static/" and
+	// "/api/" would overlap the "/{class}/..." patterns
+	root := http.NewServeMux()
+	root.Handle("/api/", newAPI(store))
+	root.Handle("GET /metrics", newMetrics(store, cfg.MetricsToken))
+	root.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(cfg.StaticDir))))
+	if local, ok := local.(*storage.LocalStorage); ok {
+		root.Handle(local.BaseUrl+"/", local.Handler())
 	}
+	root.Handle("/", mux)
+
+	return identifyClient(cfg.TrustedProxies, cfg.SecureCookies, observe(recoverPanics(limitBodies(cfg.MaxUploadBytes, root))))
 }
@@ -0,0 +1,106 @@
+// Package telemetry sets up structured logging, tracing and metrics. Log
+// records written with a request context carry that request's id, user and
+// class, and the ids of the active span so logs and traces can be joined.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetupLogging installs the default slog logger. level is debug, info, warn
+// or error; format is text or json.
+func SetupLogging(w io.Writer, level, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+// Err is the attribute errors are logged under
+func Err(err error) slog.Attr {
+	return slog.Any("err", err)
+}
+
+type attrsKey struct{}
+
+// requestAttrs collects the attributes of one request as it goes through the
+// middleware, so the final request log line sees what inner layers learned.
+type requestAttrs struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// WithRequest starts collecting attributes for a request
+func WithRequest(ctx context.Context, attrs ...slog.Attr) context.Context {
+	return context.WithValue(ctx, attrsKey{}, &requestAttrs{attrs: attrs})
+}
+
+// AddAttrs attaches attributes to the request of ctx and to its active span
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	if ra, ok := ctx.Value(attrsKey{}).(*requestAttrs); ok {
+		ra.mu.Lock()
+		ra.attrs = append(ra.attrs, attrs...)
+		ra.mu.Unlock()
+	}
+
+	span := trace.SpanFromContext(ctx)
+	for _, a := range attrs {
+		span.SetAttributes(attribute.String(a.Key, a.Value.String()))
+	}
+}
+
+func requestAttrsOf(ctx context.Context) []slog.Attr {
+	ra, ok := ctx.Value(attrsKey{}).(*requestAttrs)
+	if !ok {
+		return nil
+	}
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	return append([]slog.Attr(nil), ra.attrs...)
+}
+
+// contextHandler adds the request attributes and trace ids found in the
+// context of each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		r.AddAttrs(requestAttrsOf(ctx)...)
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// registry holds every metric served on /metrics, plus the Go runtime and
+// process collectors
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, by route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	txDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bbolt_tx_duration_seconds",
+		Help:    "Duration of bbolt transactions, by kind (view or update) and the database function that ran them.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 8),
+	}, []string{"kind", "operation"})
+
+	storageOps = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "storage_operations_total",
+		Help: "Object storage calls, by backend, operation and result (ok or error).",
+	}, []string{"backend", "operation", "result"})
+
+	storageBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "storage_bytes_total",
+		Help: "Bytes moved to and from object storage, by backend and operation.",
+	}, []string{"backend", "operation"})
+
+	submissions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "submissions_total",
+		Help: "Assignments turned in, late or not. Use increase(submissions_total[1d]) for submissions per day.",
+	}, []string{"late"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, txDuration, storageOps, storageBytes, submissions,
+	)
+}
+
+// MetricsHandler serves the registry in the Prometheus text format
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// Gauge registers a gauge whose value is read from fn on every scrape
+func Gauge(name, help string, fn func() float64) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, fn))
+}
+
+// ObserveRequest counts a served request. route is the ServeMux pattern, so
+// unmatched paths all share one label instead of one per URL.
+func ObserveRequest(method, route string, status int, d time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+// ObserveTx records how long a bbolt transaction took
+func ObserveTx(kind, operation string, d time.Duration) {
+	txDuration.WithLabelValues(kind, operation).Observe(d.Seconds())
+}
+
+// ObserveStorage counts a storage call and the bytes it moved
+func ObserveStorage(backend, operation string, bytes int64, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	storageOps.WithLabelValues(backend, operation, result).Inc()
+	if bytes > 0 {
+		storageBytes.WithLabelValues(backend, operation).Add(float64(bytes))
+	}
+}
+
+// CountSubmission counts an assignment being turned in
+func CountSubmission(late bool) {
+	submissions.WithLabelValues(strconv.FormatBool(late)).Inc()
+}
@@ -2,51 +2,138 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"frontend/database"
+	"frontend/internal/backup"
+	"frontend/internal/cli"
+	"frontend/internal/config"
 	"frontend/internal/router"
+	"frontend/internal/telemetry"
 	"frontend/storage"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-
-	"github.com/joho/godotenv"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	err := godotenv.Load(".venv") // use ".env" if you renamed it
+	cfg, args, err := config.Load(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if errors.Is(err, config.ErrUsage) {
+		os.Exit(2)
+	}
 	if err != nil {
-		log.Fatal("Error loading .venv file")
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+
+	if len(args) > 0 {
+		// Commands keep standard output for their own results
+		if err := telemetry.SetupLogging(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := cli.Run(ctx, cfg, args, os.Stdin, os.Stdout, os.Stderr)
+		stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	keyId := os.Getenv("B2_KEY_ID")
-	appKey := os.Getenv("B2_APP_KEY")
-	bucketName := os.Getenv("B2_BUCKET")
-	baseUrl := os.Getenv("B2_BASE_URL")
+	if err := cfg.ValidateServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+
+	if err := telemetry.SetupLogging(os.Stdout, cfg.LogLevel, cfg.LogFormat); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-	if keyId == "" || appKey == "" || bucketName == "" {
-		log.Fatal("missing B2 env vars")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := telemetry.SetupTracing(ctx, cfg.TracesExporter)
+	if err != nil {
+		fatal("failed to set up tracing", err)
 	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Warn("failed to flush traces", telemetry.Err(err))
+		}
+	}()
 
-	ctx := context.Background()
-	storage, err := storage.Init(ctx, keyId, appKey, bucketName, baseUrl)
+	fileStore, err := initStorage(ctx, cfg.Storage)
 	if err != nil {
-		log.Fatalf("Error initializing storage: %v", err)
+		fatal("failed to initialize storage", err)
 	}
-	fmt.Println("B2 Storage ready:", storage.BaseUrl)
 
-	store, err := database.Init("data/school.db")
+	store, err := database.Init(cfg.DBPath, database.InitOptions{TimeZone: cfg.TimeZone, Seed: cfg.Seed, SeedOut: os.Stderr})
 	if err != nil {
-		log.Fatal("failed to init database:", err)
+		fatal("failed to init database", err)
 	}
 	defer store.Close()
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		router.Router(store, storage, w, r)
-	})
+	database.StartSweeper(ctx, store, 10*time.Minute)
 
-	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+	backups := backup.Options{
+		Dir:        cfg.Backup.Dir,
+		Every:      cfg.Backup.Every,
+		KeepDaily:  cfg.Backup.KeepDaily,
+		KeepWeekly: cfg.Backup.KeepWeekly,
+	}
+	if cfg.Backup.Upload {
+		backups.Upload = storage.Instrument(fileStore)
+	}
+	backup.Start(ctx, store, backups)
+
+	server := &http.Server{Addr: cfg.Addr, Handler: router.New(store, fileStore, cfg)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("server running", slog.String("addr", cfg.Addr))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("server stopped", telemetry.Err(err))
+	}
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, telemetry.Err(err))
+	os.Exit(1)
+}
 
-	log.Println("🚀 Server running at http://localhost:3000")
-	log.Fatal(http.ListenAndServe(":3000", nil))
+// initStorage opens the object storage backend: b2, or local, which keeps
+// files on disk and serves them itself.
+func initStorage(ctx context.Context, cfg config.Storage) (storage.Storage, error) {
+	switch cfg.Backend {
+	case "local":
+		local, err := storage.InitLocal(cfg.Dir, "/files")
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("local storage ready", slog.String("root", local.Root))
+		return local, nil
+
+	default:
+		b2, err := storage.InitB2(ctx, cfg.B2KeyID, cfg.B2AppKey, cfg.B2Bucket, cfg.B2BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("B2 storage ready", slog.String("base_url", b2.BaseUrl))
+		return b2, nil
+	}
 }
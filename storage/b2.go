@@ -15,7 +15,8 @@ type B2Storage struct {
 	BaseUrl string
 }
 
-func Init(ctx context.Context, accountId, appKey, bucketName, baseUrl string) (*B2Storage, error) {
+// InitB2 connects to a Backblaze B2 bucket.
+func InitB2(ctx context.Context, accountId, appKey, bucketName, baseUrl string) (*B2Storage, error) {
 	client, err := b2.NewClient(ctx, accountId, appKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create b2 client: %w", err)
@@ -40,7 +41,7 @@ func (s *B2Storage) UploadFile(ctx context.Context, key string, r io.Reader) (st
 		return "", fmt.Errorf("failed to close writer: %w", err)
 	}
 
-	return fmt.Sprintf("https://%s/file/%s/%s", s.BaseUrl, s.Bucket.Name(), key), nil
+	return s.URL(key), nil
 }
 
 func (s *B2Storage) DownloadFile(ctx context.Context, key string, w io.Writer) error {
@@ -58,8 +59,7 @@ func (s *B2Storage) DownloadFile(ctx context.Context, key string, w io.Writer) e
 
 func (s *B2Storage) DeleteFile(ctx context.Context, path string) error {
 	// Convert friendly URL → key if needed
-	friendlyPrefix := fmt.Sprintf("https://%s/file/%s/", s.BaseUrl, s.Bucket.Name())
-	key := strings.ReplaceAll(path, friendlyPrefix, "")
+	key := strings.TrimPrefix(path, s.URL(""))
 
 	obj := s.Bucket.Object(key)
 	if err := obj.Delete(ctx); err != nil {
@@ -67,3 +67,7 @@ func (s *B2Storage) DeleteFile(ctx context.Context, path string) error {
 	}
 	return nil
 }
+
+func (s *B2Storage) URL(key string) string {
+	return fmt.Sprintf("https://%s/file/%s/%s", s.BaseUrl, s.Bucket.Name(), key)
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"frontend/internal/telemetry"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// instrumentedStorage wraps every call to a backend in a span and counts it,
+// with the bytes moved, in the storage metrics
+type instrumentedStorage struct {
+	Storage
+	backend string
+}
+
+// Instrument returns s with a span and metrics around each upload, download
+// and delete.
+func Instrument(s Storage) Storage {
+	var backend string
+	switch s.(type) {
+	case *B2Storage:
+		backend = "b2"
+	case *LocalStorage:
+		backend = "local"
+	default:
+		backend = fmt.Sprintf("%T", s)
+	}
+	return instrumentedStorage{s, backend}
+}
+
+func (t instrumentedStorage) UploadFile(ctx context.Context, key string, r io.Reader) (string, error) {
+	ctx, span := telemetry.Start(ctx, "storage.UploadFile", attribute.String("storage.key", key))
+	cr := &countingReader{r: r}
+	url, err := t.Storage.UploadFile(ctx, key, cr)
+	span.SetAttributes(attribute.Int64("storage.bytes", cr.n))
+	telemetry.End(span, err)
+	telemetry.ObserveStorage(t.backend, "upload", cr.n, err)
+	return url, err
+}
+
+func (t instrumentedStorage) DownloadFile(ctx context.Context, key string, w io.Writer) error {
+	ctx, span := telemetry.Start(ctx, "storage.DownloadFile", attribute.String("storage.key", key))
+	cw := &countingWriter{w: w}
+	err := t.Storage.DownloadFile(ctx, key, cw)
+	span.SetAttributes(attribute.Int64("storage.bytes", cw.n))
+	telemetry.End(span, err)
+	telemetry.ObserveStorage(t.backend, "download", cw.n, err)
+	return err
+}
+
+func (t instrumentedStorage) DeleteFile(ctx context.Context, path string) error {
+	ctx, span := telemetry.Start(ctx, "storage.DeleteFile", attribute.String("storage.path", path))
+	err := t.Storage.DeleteFile(ctx, path)
+	telemetry.End(span, err)
+	telemetry.ObserveStorage(t.backend, "delete", 0, err)
+	return err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage keeps files on the local disk and serves them through the
+// app itself, so the server can run without any external bucket.
+type LocalStorage struct {
+	Root    string // directory where the files are written
+	BaseUrl string // URL prefix the files are served under, e.g. "/files"
+}
+
+// InitLocal prepares root to hold the uploaded files.
+func InitLocal(root, baseUrl string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage dir: %w", err)
+	}
+	return &LocalStorage{Root: root, BaseUrl: strings.TrimSuffix(baseUrl, "/")}, nil
+}
+
+// path resolves key inside Root, refusing keys that would escape it.
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(clean)), nil
+}
+
+func (s *LocalStorage) UploadFile(ctx context.Context, key string, r io.Reader) (string, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", fmt.Errorf("failed to create dir: %w", err)
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to close file: %w", err)
+	}
+
+	return s.URL(key), nil
+}
+
+func (s *LocalStorage) DownloadFile(ctx context.Context, key string, w io.Writer) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to read the file: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) DeleteFile(ctx context.Context, path string) error {
+	// Convert friendly URL → key if needed
+	key := strings.TrimPrefix(path, s.URL(""))
+
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil {
+		return fmt.Errorf("failed to delete file %q: %w", key, err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.BaseUrl + "/" + key
+}
+
+// Handler serves the stored files, meant to be mounted under BaseUrl.
+// Directory listings are not exposed.
+func (s *LocalStorage) Handler() http.Handler {
+	files := http.StripPrefix(s.BaseUrl+"/", http.FileServer(http.Dir(s.Root)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
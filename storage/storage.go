@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// Storage is the object store used for assignment and submission files.
+// Keys are slash separated paths such as "assignments/3/guia.pdf"; the
+// public URL returned by UploadFile is what gets persisted on the models.
+type Storage interface {
+	// UploadFile writes r under key and returns the public URL of the object.
+	UploadFile(ctx context.Context, key string, r io.Reader) (string, error)
+	// DownloadFile copies the object stored under key into w.
+	DownloadFile(ctx context.Context, key string, w io.Writer) error
+	// DeleteFile removes an object, accepting either its key or its public URL.
+	DeleteFile(ctx context.Context, path string) error
+	// URL returns the public URL for key.
+	URL(key string) string
+}
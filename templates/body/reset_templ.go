@@ -0,0 +1,70 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.3.943
+package body
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+// Reset is the page an admin issued reset link opens. token is empty when the
+// link is unknown, expired or already used.
+func Reset(token string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!-- Background --><div class=\"absolute inset-0\"><img src=\"/static/assets/log-background.jpg\" alt=\"Background\" class=\"w-full h-full object-cover\"></div><!-- Gradient overlay --><div class=\"absolute inset-0 bg-gradient-to-b from-black/70 via-black/40 to-black/70\"></div><div class=\"relative z-10 w-full max-w-md px-6\"><div class=\"backdrop-blur-md bg-white/10 border border-white/20 shadow-xl rounded-3xl p-8\"><h1 class=\"text-3xl font-bold text-center text-white tracking-wide\">Nueva <span class=\"text-red-500\">contraseña</span></h1><div class=\"w-16 h-1 bg-red-500 mx-auto mt-3 rounded-full\"></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if token == "" {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<p class=\"text-center text-gray-200 mt-6\">El enlace no es válido o ya fue usado. Pide uno nuevo a la administración.</p><a href=\"/login\" class=\"block text-center text-red-300 hover:text-red-200 mt-6\">Volver al inicio de sesión</a>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		} else {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<p class=\"text-center text-gray-200 mt-4\">Elige una contraseña para tu cuenta</p><form hx-post=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var2 string
+			templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs("/login/reset/" + token)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/body/reset.templ`, Line: 29, Col: 47}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "\" hx-target=\"#login-msg\" hx-swap=\"innerHTML\" class=\"mt-8 space-y-5\"><div><input type=\"password\" name=\"password\" placeholder=\"Nueva contraseña\" autocomplete=\"new-password\" class=\"w-full px-4 py-3 rounded-xl bg-white/80 border border-gray-200 focus:ring-2 focus:ring-red-500 focus:outline-none placeholder-gray-500 caret-red-500 text-gray-800\" required></div><div><input type=\"password\" name=\"confirm\" placeholder=\"Repite la contraseña\" autocomplete=\"new-password\" class=\"w-full px-4 py-3 rounded-xl bg-white/80 border border-gray-200 focus:ring-2 focus:ring-red-500 focus:outline-none placeholder-gray-500 caret-red-500 text-gray-800\" required></div><button type=\"submit\" class=\"w-full py-3 rounded-full bg-gradient-to-r from-red-500 to-red-700 text-white font-semibold shadow-lg hover:scale-105 transform transition duration-200 cursor-pointer\">Guardar contraseña</button></form><!-- Message area --> <div id=\"login-msg\" class=\"text-center mt-4 text-sm text-red-300\"></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</div></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+var _ = templruntime.GeneratedTemplate
@@ -13,7 +13,7 @@ import (
 	"frontend/templates/components/assignment/studentSubmissionSlot"
 )
 
-func AssignmentDetailProfessor(classId int, a *models.Assignment, submissions []*models.Submission, pastDate bool) templ.Component {
+func AssignmentDetailProfessor(classId int, a *models.Assignment, submissions []*models.Submission, beforeDue bool) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -38,7 +38,7 @@ func AssignmentDetailProfessor(classId int, a *models.Assignment, submissions []
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		if pastDate {
+		if beforeDue {
 			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<p class=\"text-gray-500 text-center\">La fecha de entrega todavía no ha llegado.</p>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
@@ -78,7 +78,7 @@ func AssignmentDetailProfessor(classId int, a *models.Assignment, submissions []
 				}
 			} else {
 				for _, s := range submissions {
-					templ_7745c5c3_Err = studentSubmissionSlot.StudentSubmissionSlot(classId, a.Id, s).Render(ctx, templ_7745c5c3_Buffer)
+					templ_7745c5c3_Err = studentSubmissionSlot.StudentSubmissionSlot(classId, a, s).Render(ctx, templ_7745c5c3_Buffer)
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
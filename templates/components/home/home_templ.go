@@ -13,7 +13,8 @@ import (
 	"frontend/templates/components/class"
 )
 
-func Home(slotsInfo []*models.Class, professor bool) templ.Component {
+// professorOf tells, per class id, whether the user teaches that class.
+func Home(slotsInfo []*models.Class, professorOf map[int]bool, admin bool) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -34,17 +35,27 @@ func Home(slotsInfo []*models.Class, professor bool) templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!-- Content --><div class=\"grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-3 gap-6\"><!-- Class card -->")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!-- Content --><div class=\"grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-3 gap-6\"><!-- Admin card -->")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if admin {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<div class=\"bg-white border border-gray-200 shadow hover:shadow-md transition rounded-lg flex flex-col\"><div class=\"p-5 flex-1\"><h2 class=\"text-lg font-semibold text-gray-900\">Administración</h2><p class=\"text-gray-600 text-sm mt-1\">Usuarios, clases y materias de la escuela</p></div><div class=\"flex divide-x divide-gray-200 border-t border-gray-200 rounded-b-lg overflow-hidden\"><button hx-get=\"/admin/usuarios\" hx-target=\"#content\" hx-push-url=\"true\" class=\"flex-1 py-2 text-sm text-gray-700 hover:bg-gray-100 transition cursor-pointer\">Usuarios</button> <button hx-get=\"/admin/clases\" hx-target=\"#content\" hx-push-url=\"true\" class=\"flex-1 py-2 text-sm text-gray-700 hover:bg-gray-100 transition cursor-pointer\">Clases</button> <button hx-get=\"/admin/materias\" hx-target=\"#content\" hx-push-url=\"true\" class=\"flex-1 py-2 text-sm text-gray-700 hover:bg-gray-100 transition cursor-pointer\">Materias</button></div></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<!-- Class card -->")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		for _, item := range slotsInfo {
-			templ_7745c5c3_Err = class.ClassSlot(item, professor).Render(ctx, templ_7745c5c3_Buffer)
+			templ_7745c5c3_Err = class.ClassSlot(item, professorOf[item.Id]).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -8,6 +8,8 @@ package views
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
+import "frontend/internal/csrf"
+
 func Layout(body templ.Component) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
@@ -29,7 +31,30 @@ func Layout(body templ.Component) templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\" x-data><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><script src=\"https://unpkg.com/htmx.org@2.0.7\"></script><script src=\"https://unpkg.com/alpinejs\" defer></script><link href=\"/static/css/output.css\" rel=\"stylesheet\"><link rel=\"stylesheet\" href=\"https://cdn.jsdelivr.net/npm/flatpickr/dist/flatpickr.min.css\"></head><body class=\"h-screen w-screen flex items-center justify-center bg-black relative\"><script src=\"https://cdn.jsdelivr.net/npm/flatpickr\"></script><script src=\"https://cdn.jsdelivr.net/npm/flatpickr/dist/l10n/es.js\"></script>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\" x-data><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><script src=\"https://unpkg.com/htmx.org@2.0.7\"></script><script src=\"https://unpkg.com/alpinejs\" defer></script><link href=\"/static/css/output.css\" rel=\"stylesheet\"><link rel=\"stylesheet\" href=\"https://cdn.jsdelivr.net/npm/flatpickr/dist/flatpickr.min.css\"></head><body class=\"h-screen w-screen flex items-center justify-center bg-black relative\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if csrf.Token(ctx) != "" {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, " hx-headers=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var2 string
+			templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(csrf.Headers(ctx))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/views/layout.templ`, Line: 18, Col: 32}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "><script src=\"https://cdn.jsdelivr.net/npm/flatpickr\"></script><script src=\"https://cdn.jsdelivr.net/npm/flatpickr/dist/l10n/es.js\"></script>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -37,7 +62,7 @@ func Layout(body templ.Component) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<script>\n\tfunction initFlatpickr() {\n  flatpickr(\"#due-date\", {\n    dateFormat: \"d/m/Y\",\n    altInput: true,\n    altFormat: \"d/m/Y\",\n    defaultDate: document.querySelector(\"#due-date\")?.value || null,\n    locale: flatpickr.l10ns.es,   // ✅ use the loaded Spanish locale\n  });\n}\n\n\n document.addEventListener(\"DOMContentLoaded\", initFlatpickr);\n document.addEventListener(\"htmx:afterSwap\", initFlatpickr);\n</script><script>\nfunction fileManager() {\n  return {\n    files: {},\n\n    initExisting(urls) {\n      if (!urls) return;\n      urls.forEach(url => {\n        const name = url.split('/').pop();\n        this.files[name] = url;\n      });\n      this.syncUploads();\n    },\n\n    addFiles(list) {\n      Array.from(list).forEach(f => {\n        this.files[f.name] = f;\n      });\n      this.syncUploads();\n    },\n\n    remove(name) {\n      delete this.files[name];\n      this.syncUploads();\n    },\n\n    syncUploads() {\n      const dt = new DataTransfer();\n      for (const value of Object.values(this.files)) {\n        if (value instanceof File) dt.items.add(value);\n      }\n      this.$refs.uploads.files = dt.files;\n    }\n  }\n}\n</script></body></html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<script>\n\tfunction initFlatpickr() {\n  flatpickr(\"#due-date\", {\n    dateFormat: \"d/m/Y H:i\",\n    altInput: true,\n    altFormat: \"d/m/Y H:i\",\n    enableTime: true,\n    time_24hr: true,\n    defaultDate: document.querySelector(\"#due-date\")?.value || null,\n    locale: flatpickr.l10ns.es,   // ✅ use the loaded Spanish locale\n  });\n}\n\n\n document.addEventListener(\"DOMContentLoaded\", initFlatpickr);\n document.addEventListener(\"htmx:afterSwap\", initFlatpickr);\n</script><script>\nfunction fileManager() {\n  return {\n    files: {},\n\n    initExisting(urls) {\n      if (!urls) return;\n      urls.forEach(url => {\n        const name = url.split('/').pop();\n        this.files[name] = url;\n      });\n      this.syncUploads();\n    },\n\n    addFiles(list) {\n      Array.from(list).forEach(f => {\n        this.files[f.name] = f;\n      });\n      this.syncUploads();\n    },\n\n    remove(name) {\n      delete this.files[name];\n      this.syncUploads();\n    },\n\n    syncUploads() {\n      const dt = new DataTransfer();\n      for (const value of Object.values(this.files)) {\n        if (value instanceof File) dt.items.add(value);\n      }\n      this.$refs.uploads.files = dt.files;\n    }\n  }\n}\n\nfunction rubricEditor(initial) {\n  return {\n    criteria: initial?.criteria ?? [],\n\n    addCriterion() {\n      this.criteria.push({ name: '', levels: [{ name: '', points: 0 }] });\n    },\n\n    addLevel(criterion) {\n      criterion.levels.push({ name: '', points: 0 });\n    }\n  }\n}\n</script></body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}